@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metas por pergunta (--targets) avaliadas depois do export. Uma meta não
+// atingida vai para o arquivo de alertas (--alerts), aparece em vermelho no
+// slide da pergunta e faz o programa sair com exitAlert, para o agendador
+// poder acionar a coordenação da qualidade.
+//
+// Sintaxe: "[qN:]indicador>=valor" ou "<=", separadas por vírgula ou uma por
+// linha num arquivo (linhas com # são comentário). Sem qN, a meta vale para
+// todas as perguntas em que o indicador se aplica.
+//
+//	topbox>=85, q11:sim>=90, ruim<=5
+
+// exitAlert é o código de saída quando alguma meta não foi atingida.
+const exitAlert = 3
+
+// Indicadores, todos em % e sem "Não utilizei" na base.
+const (
+	metricTopBox   = "topbox"   // Excelente, em perguntas de escala
+	metricPositive = "positive" // Excelente/Boa ou Sim
+	metricYes      = "yes"      // Sim, em perguntas Sim/Não
+	metricBottom   = "bottom"   // Ruim, em perguntas de escala
+)
+
+var metricAliases = map[string]string{
+	"topbox": metricTopBox, "top-box": metricTopBox, "excelente": metricTopBox,
+	"positive": metricPositive, "positivas": metricPositive,
+	"yes": metricYes, "sim": metricYes,
+	"bottom": metricBottom, "bottombox": metricBottom, "ruim": metricBottom,
+}
+
+type target struct {
+	Question int // 0 = todas
+	Metric   string
+	Op       string // ">=" ou "<="
+	Value    float64
+	Raw      string
+}
+
+// parseTargets aceita a lista na própria flag ou o caminho de um arquivo.
+func parseTargets(spec string) ([]target, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	var items []string
+	if st, err := os.Stat(spec); err == nil && !st.IsDir() {
+		f, err := os.Open(spec)
+		if err != nil {
+			return nil, fmt.Errorf("--targets: %w", err)
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if i := strings.Index(line, "#"); i >= 0 {
+				line = strings.TrimSpace(line[:i])
+			}
+			items = append(items, strings.Split(line, ",")...)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("--targets: %w", err)
+		}
+	} else {
+		items = strings.Split(spec, ",")
+	}
+
+	var out []target
+	for _, it := range items {
+		it = strings.TrimSpace(it)
+		if it == "" {
+			continue
+		}
+		t, err := parseTarget(it)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("--targets %q has no targets", spec)
+	}
+	return out, nil
+}
+
+func parseTarget(s string) (target, error) {
+	t := target{Raw: s}
+	rest := strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	if i := strings.Index(rest, ":"); i >= 0 {
+		q := strings.TrimPrefix(strings.TrimPrefix(rest[:i], "questao"), "q")
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > 20 || isExcludedQuestion(n) {
+			return t, fmt.Errorf("invalid target %q: question must be q1..q20 (except 16 and 20)", s)
+		}
+		t.Question = n
+		rest = rest[i+1:]
+	}
+	for _, op := range []string{">=", "<="} {
+		if i := strings.Index(rest, op); i > 0 {
+			metric, ok := metricAliases[rest[:i]]
+			if !ok {
+				return t, fmt.Errorf("invalid target %q: unknown indicator %q (use topbox, positive, yes or bottom)", s, rest[:i])
+			}
+			v, err := strconv.ParseFloat(strings.TrimSuffix(strings.ReplaceAll(rest[i+2:], ",", "."), "%"), 64)
+			if err != nil || v < 0 || v > 100 {
+				return t, fmt.Errorf("invalid target %q: value must be a percentage 0-100", s)
+			}
+			t.Metric, t.Op, t.Value = metric, op, v
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("invalid target %q (expected e.g. topbox>=85 or q11:yes>=90)", s)
+}
+
+// metricValue calcula o indicador; ok=false se não se aplica à pergunta.
+func metricValue(metric string, counts map[string]int) (pct float64, n int, ok bool) {
+	byCode := map[string]int{}
+	for label, c := range counts {
+		code, known := answerCode(label)
+		if !known {
+			if _, isCode := msgs.Answers[label]; !isCode {
+				continue
+			}
+			code = label
+		}
+		byCode[code] += c
+	}
+	rating := byCode["1"] + byCode["2"] + byCode["3"] + byCode["4"]
+	yesNo := byCode["6"] + byCode["7"]
+	share := func(k, base int) (float64, int, bool) {
+		if base == 0 {
+			return 0, 0, false
+		}
+		return float64(k) / float64(base) * 100, base, true
+	}
+	switch metric {
+	case metricTopBox:
+		return share(byCode["4"], rating)
+	case metricBottom:
+		return share(byCode["1"], rating)
+	case metricYes:
+		return share(byCode["6"], yesNo)
+	case metricPositive:
+		pos, base := positiveCounts(counts)
+		return share(pos, base)
+	}
+	return 0, 0, false
+}
+
+// alert é uma meta não atingida.
+type alert struct {
+	Question int     `json:"questao"`
+	Title    string  `json:"titulo"`
+	Metric   string  `json:"indicador"`
+	Target   string  `json:"meta"`
+	Value    float64 `json:"valor"`
+	N        int     `json:"n"`
+	LowN     bool    `json:"n_baixo"`
+}
+
+func (t target) breached(v float64) bool {
+	if t.Op == "<=" {
+		return v > t.Value
+	}
+	return v < t.Value
+}
+
+// evaluateTargets devolve as metas não atingidas no total do período. Uma
+// meta específica (qN:) substitui a geral do mesmo indicador.
+func evaluateTargets(ac *answerCounts, targets []target, minN int) []alert {
+	var out []alert
+	for i, qc := range ac.Questions {
+		for _, t := range targets {
+			if t.Question != 0 && t.Question != qc.Number {
+				continue
+			}
+			if t.Question == 0 && hasSpecificTarget(targets, qc.Number, t.Metric) {
+				continue
+			}
+			v, n, ok := metricValue(t.Metric, ac.Total[i])
+			if !ok || !t.breached(v) {
+				continue
+			}
+			out = append(out, alert{
+				Question: qc.Number,
+				Title:    qc.Title,
+				Metric:   t.Metric,
+				Target:   t.Op + " " + strconv.FormatFloat(t.Value, 'f', -1, 64),
+				Value:    v,
+				N:        n,
+				LowN:     n < minN,
+			})
+		}
+	}
+	return out
+}
+
+func hasSpecificTarget(targets []target, question int, metric string) bool {
+	for _, t := range targets {
+		if t.Question == question && t.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// slideAlert: texto em destaque no slide da pergunta (vazio se nenhuma meta falhou).
+func slideAlert(alerts []alert, question int) string {
+	var parts []string
+	for _, a := range alerts {
+		if a.Question == question {
+			parts = append(parts, fmt.Sprintf(msgs.AlertItem, msgs.metricName(a.Metric), a.Value, a.Target))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return msgs.AlertSlide + " " + strings.Join(parts, "; ")
+}
+
+type alertsFile struct {
+	Period    string   `json:"periodo"`
+	Generated string   `json:"gerado_em"`
+	Targets   []string `json:"metas"`
+	Alerts    []alert  `json:"alertas"`
+}
+
+// alertPaths resolve --alerts: "auto" grava <csv>_alertas.json e .md; um
+// caminho grava só o formato da extensão (.json ou .md).
+func alertPaths(flagVal, csvPath string) ([]string, error) {
+	flagVal = strings.TrimSpace(flagVal)
+	if strings.EqualFold(flagVal, "auto") {
+		base := strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "_alertas"
+		return []string{base + ".json", base + ".md"}, nil
+	}
+	switch strings.ToLower(filepath.Ext(flagVal)) {
+	case ".json", ".md":
+		return []string{flagVal}, nil
+	}
+	return nil, fmt.Errorf("--alerts must be auto or a .json/.md path: %s", flagVal)
+}
+
+func writeAlerts(paths []string, period string, targets []target, alerts []alert) error {
+	doc := alertsFile{Period: period, Generated: time.Now().Format(time.RFC3339), Alerts: alerts}
+	if doc.Alerts == nil {
+		doc.Alerts = []alert{}
+	}
+	for _, t := range targets {
+		doc.Targets = append(doc.Targets, t.Raw)
+	}
+	for _, p := range paths {
+		var b []byte
+		if strings.EqualFold(filepath.Ext(p), ".json") {
+			var err error
+			if b, err = json.MarshalIndent(doc, "", "  "); err != nil {
+				return fmt.Errorf("marshal alerts: %w", err)
+			}
+		} else {
+			b = []byte(alertsMarkdown(doc))
+		}
+		if err := os.WriteFile(p, b, 0o644); err != nil {
+			return fmt.Errorf("write alerts: %w", err)
+		}
+	}
+	return nil
+}
+
+func alertsMarkdown(doc alertsFile) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s - %s\n\n", msgs.AlertsTitle, doc.Period)
+	fmt.Fprintf(&sb, "%s: `%s`\n\n", msgs.AlertsTargets, strings.Join(doc.Targets, "`, `"))
+	if len(doc.Alerts) == 0 {
+		sb.WriteString(msgs.AlertsNone + "\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "| %s |\n|---|---|---|---|---|\n", strings.Join(msgs.AlertsColumns[:], " | "))
+	for _, a := range doc.Alerts {
+		n := strconv.Itoa(a.N)
+		if a.LowN {
+			n += " ⚠"
+		}
+		fmt.Fprintf(&sb, "| %d. %s | %s | %s%% | %.1f%% | %s |\n", a.Question, a.Title, msgs.metricName(a.Metric), a.Target, a.Value, n)
+	}
+	return sb.String()
+}
+
+// runAlerts avalia as metas nas respostas do período, grava o arquivo (se
+// pedido) e devolve as metas não atingidas. period vazio = pelas datas lidas.
+func runAlerts(alertsFlag string, in *periodAnswers, period string, opts pptxOptions) ([]alert, error) {
+	if len(opts.Targets) == 0 {
+		return nil, nil
+	}
+	merged, err := in.load()
+	if err != nil {
+		return nil, err
+	}
+	alerts := evaluateTargets(merged.Counts, opts.Targets, opts.MinN)
+	if period == "" && !merged.First.IsZero() {
+		period = msgs.periodShort(monthBounds(merged.First, merged.Last))
+	}
+	if strings.TrimSpace(alertsFlag) != "" {
+		paths, err := alertPaths(alertsFlag, in.Paths[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := writeAlerts(paths, period, opts.Targets, alerts); err != nil {
+			return nil, err
+		}
+		fmt.Printf("OK: %d alertas gravados em %s\n", len(alerts), strings.Join(paths, ", "))
+	}
+	return alerts, nil
+}
+
+// alertsErr: a execução terminou, mas n metas não foram atingidas (sai com
+// exitAlert). Vem por último, depois do deck, para os arquivos saírem mesmo
+// com alerta.
+type alertsErr struct{ N int }
+
+func (e *alertsErr) Error() string { return fmt.Sprintf("%d targets missed", e.N) }
+
+func alertsError(n int) error {
+	if n == 0 {
+		return nil
+	}
+	return &alertsErr{N: n}
+}
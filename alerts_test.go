@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTargets(t *testing.T) {
+	got, err := parseTargets(" topbox>=85, Q11:sim >= 90,ruim<=5%, questao3:positivas>=72.5 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []target{
+		{Metric: metricTopBox, Op: ">=", Value: 85},
+		{Question: 11, Metric: metricYes, Op: ">=", Value: 90},
+		{Metric: metricBottom, Op: "<=", Value: 5},
+		{Question: 3, Metric: metricPositive, Op: ">=", Value: 72.5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("targets = %+v", got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Question != w.Question || g.Metric != w.Metric || g.Op != w.Op || g.Value != w.Value {
+			t.Errorf("target %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestParseTargetsErrors(t *testing.T) {
+	tests := []struct{ spec, want string }{
+		{"topbox>80", "expected e.g."},
+		{"topbox=80", "expected e.g."},
+		{">=80", "expected e.g."},
+		{"nps>=80", "unknown indicator"},
+		{"topbox>=101", "percentage 0-100"},
+		{"topbox>=-1", "percentage 0-100"},
+		{"topbox>=alto", "percentage 0-100"},
+		{"q16:topbox>=80", "question must be"},
+		{"q21:topbox>=80", "question must be"},
+		{"qx:topbox>=80", "question must be"},
+		{" , ,", "has no targets"},
+	}
+	for _, tt := range tests {
+		_, err := parseTargets(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseTargets(%q) err = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+	if got, err := parseTargets("  "); got != nil || err != nil {
+		t.Errorf("empty spec = %v, %v; want no targets", got, err)
+	}
+}
+
+func TestParseTargetsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metas.txt")
+	content := "# metas da qualidade\ntopbox>=85 # geral\n\nq11:yes>=90, ruim<=5\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseTargets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[1].Question != 11 || got[2].Metric != metricBottom {
+		t.Errorf("targets = %+v", got)
+	}
+}
+
+func TestTargetBoundary(t *testing.T) {
+	tests := []struct {
+		spec     string
+		counts   map[string]int
+		breached bool
+	}{
+		// >= é atingida no valor exato; < fica abaixo.
+		{"topbox>=85", scaleCounts(100, 85, 0), false},
+		{"topbox>=85", scaleCounts(100, 84, 0), true},
+		// <= é atingida no valor exato; > fica acima.
+		{"ruim<=5", scaleCounts(100, 50, 5), false},
+		{"ruim<=5", scaleCounts(100, 50, 6), true},
+		// "Não utilizei" fica fora da base: 85 de 100, não de 120.
+		{"topbox>=85", map[string]int{"Excelente": 85, "Boa": 15, "Não utilizei": 20}, false},
+		{"yes>=90", map[string]int{"Sim": 90, "Não": 10}, false},
+		{"yes>=90", map[string]int{"Sim": 89, "Não": 11}, true},
+	}
+	for _, tt := range tests {
+		targets, err := parseTargets(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ac := &answerCounts{Questions: []questionCol{{Number: 1, Title: "RECEPÇÃO"}}, Total: []map[string]int{tt.counts}}
+		alerts := evaluateTargets(ac, targets, 0)
+		if (len(alerts) > 0) != tt.breached {
+			t.Errorf("%s on %v: alerts = %+v, want breached %v", tt.spec, tt.counts, alerts, tt.breached)
+		}
+	}
+}
+
+func TestEvaluateTargetsMinN(t *testing.T) {
+	ac := &answerCounts{
+		Questions: []questionCol{{Number: 1, Title: "RECEPÇÃO"}, {Number: 2, Title: "LIMPEZA"}, {Number: 11, Title: "RECOMENDARIA"}},
+		Total: []map[string]int{
+			scaleCounts(100, 50, 0), // n alto
+			scaleCounts(10, 5, 0),   // n abaixo de --min-n
+			{"Sim": 80, "Não": 20},  // Sim/Não: topbox não se aplica
+		},
+	}
+	targets, err := parseTargets("topbox>=85")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := evaluateTargets(ac, targets, 30)
+	// A pergunta 11 é pulada (sem base de escala); a 2 continua alertando,
+	// marcada com n_baixo para quem lê decidir o peso.
+	if len(alerts) != 2 {
+		t.Fatalf("alerts = %+v, want questions 1 and 2", alerts)
+	}
+	if alerts[0].Question != 1 || alerts[0].LowN || alerts[0].N != 100 {
+		t.Errorf("question 1: %+v", alerts[0])
+	}
+	if alerts[1].Question != 2 || !alerts[1].LowN || alerts[1].N != 10 {
+		t.Errorf("question 2: %+v", alerts[1])
+	}
+	if got := evaluateTargets(ac, targets, 0); got[1].LowN {
+		t.Error("--min-n=0 must not mark low n")
+	}
+
+	// Meta específica substitui a geral do mesmo indicador.
+	targets, err = parseTargets("topbox>=85, q1:topbox>=40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := evaluateTargets(ac, targets, 30); len(alerts) != 1 || alerts[0].Question != 2 {
+		t.Errorf("specific target: alerts = %+v, want only question 2", alerts)
+	}
+}
+
+func TestAlertsExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{alertsError(0), 0},
+		{alertsError(2), exitAlert},
+		{fmt.Errorf("backfill: %w", alertsError(1)), exitAlert},
+		{withStage(stageReport, fmt.Errorf("alerts: %w", os.ErrNotExist)), exitReport},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+	if exitAlert != 3 {
+		t.Errorf("exitAlert = %d; the scheduler expects 3", exitAlert)
+	}
+}
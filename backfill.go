@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backfill: --from-month 2024-01 --to-month 2025-12 gera um relatório por mês,
+// cada um na sua pasta (<--out>/2024_01/relatorio_2024_01.csv, ...). Os meses
+// rodam em paralelo num pool limitado (--workers) que compartilha o mesmo
+// *sql.DB; a falha de um mês não interrompe os outros.
+
+type backfillJob struct {
+	Start, End time.Time // [Start, End)
+	Dir        string
+}
+
+type backfillResult struct {
+	Job     backfillJob
+	Rows    int
+	Skipped int
+	Paths   []string
+	Alerts  int // metas não atingidas (--targets)
+	Err     error
+	Elapsed time.Duration
+}
+
+type backfillOptions struct {
+	Workers int
+	BaseDir string
+	PPTX    bool
+	// Reports: qualquer --kpi, --heatmap etc. não vazio grava <csv>_kpi.csv
+	// (e os demais) na pasta do mês.
+	Reports reportOptions
+}
+
+// backfillBaseDir valida --out no backfill: é a pasta base, não um arquivo.
+// Um nome com extensão de saída (relatorio.csv) ou um arquivo existente dá
+// erro, em vez de virar a pasta relatorio.csv/2024_01/.
+func backfillBaseDir(out string) (string, error) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return ".", nil
+	}
+	switch strings.ToLower(filepath.Ext(out)) {
+	case ".csv", ".jsonl", ".parquet", ".pptx":
+		return "", fmt.Errorf("with --from-month/--to-month, --out is the base folder (one subfolder per month), not a file: %s", out)
+	}
+	if st, err := os.Stat(out); err == nil && !st.IsDir() {
+		return "", fmt.Errorf("with --from-month/--to-month, --out must be a folder; %s is a file", out)
+	}
+	return out, nil
+}
+
+// backfillMonths devolve os meses de from a to (inclusive, "2006-01") no fuso loc.
+func backfillMonths(from, to string, loc *time.Location) ([]time.Time, error) {
+	parse := func(flagName, s string) (time.Time, error) {
+		t, err := time.ParseInLocation("2006-01", strings.TrimSpace(s), loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --%s %q (use YYYY-MM)", flagName, s)
+		}
+		return t, nil
+	}
+	first, err := parse("from-month", from)
+	if err != nil {
+		return nil, err
+	}
+	last, err := parse("to-month", to)
+	if err != nil {
+		return nil, err
+	}
+	if last.Before(first) {
+		return nil, fmt.Errorf("--to-month %s is before --from-month %s", to, from)
+	}
+	var out []time.Time
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func runBackfill(db *sql.DB, engine string, months []time.Time, opts backfillOptions) []backfillResult {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(months) {
+		workers = len(months)
+	}
+	// Uma conexão por worker; mais que isso só ficaria ocioso no pool.
+	db.SetMaxOpenConns(workers)
+
+	results := make([]backfillResult, len(months))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	// O --publish grava na mesma tabela; um mês por vez evita lock no SQLite
+	// e deadlock de upsert no MySQL.
+	var pubMu sync.Mutex
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				m := months[i]
+				job := backfillJob{
+					Start: m,
+					End:   m.AddDate(0, 1, 0),
+					Dir:   filepath.Join(opts.BaseDir, m.Format("2006_01")),
+				}
+				results[i] = runBackfillMonth(db, engine, job, opts, &pubMu)
+			}
+		}()
+	}
+	for i := range months {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func runBackfillMonth(db *sql.DB, engine string, job backfillJob, opts backfillOptions, pubMu *sync.Mutex) (res backfillResult) {
+	res.Job = job
+	began := time.Now()
+	defer func() { res.Elapsed = time.Since(began) }()
+
+	if err := os.MkdirAll(job.Dir, 0o755); err != nil {
+		res.Err = withStage(stageWrite, fmt.Errorf("create output dir: %w", err))
+		return res
+	}
+	last := job.End.Add(-time.Nanosecond)
+	outPath := filepath.Join(job.Dir, defaultOutName(job.Start, last))
+
+	// Mesmo limite do modo normal, mas por mês.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	ropts := opts.Reports.batch()
+	ropts.PubMu = pubMu
+	if opts.PPTX {
+		ropts.PPTX = filepath.Join(job.Dir, defaultPPTXName(job.Start, last))
+	}
+	pr, err := runPeriodReports(ctx, db, engine, job.Start, job.End, outPath, ropts)
+	res.Rows, res.Skipped, res.Paths, res.Alerts, res.Err = pr.Rows, pr.Skipped, pr.Paths, pr.Alerts, err
+	return res
+}
+
+// batchError: um ou mais meses do backfill (ou unidades do --units)
+// falharam. O código de saída segue a etapa do primeiro que falhou; nas
+// métricas conta cada um.
+type batchError struct {
+	Op, What      string // "backfill", "months"
+	Failed, Total int
+	Errs          []error
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%s: %d of %d %s failed", e.Op, e.Failed, e.Total, e.What)
+}
+
+func (e *batchError) Unwrap() error { return e.Errs[0] }
+
+func backfillErr(results []backfillResult, failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	e := &batchError{Op: "backfill", What: "months", Failed: failed, Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			e.Errs = append(e.Errs, r.Err)
+		}
+	}
+	return e
+}
+
+// printBackfillSummary imprime uma linha por mês e devolve quantos falharam.
+func printBackfillSummary(results []backfillResult) int {
+	failed := 0
+	fmt.Println("Resumo do backfill:")
+	for _, r := range results {
+		month := r.Job.Start.Format("2006-01")
+		if r.Err != nil {
+			failed++
+			fmt.Printf("  %s  FALHOU  %v\n", month, r.Err)
+			continue
+		}
+		alerts := ""
+		if r.Alerts > 0 {
+			alerts = fmt.Sprintf(" - %d metas não atingidas", r.Alerts)
+		}
+		fmt.Printf("  %s  OK      %d linhas (%d duplicadas removidas) em %s [%s]%s\n", month, r.Rows, r.Skipped, r.Job.Dir, r.Elapsed.Round(time.Millisecond), alerts)
+	}
+	if failed == 0 {
+		fmt.Printf("OK: %d meses gerados\n", len(results))
+	}
+	return failed
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackfillBaseDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notas")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		out, want string
+		wantErr   bool
+	}{
+		{"", ".", false},
+		{"auditoria", "auditoria", false},
+		{" relatorios.2025 ", "relatorios.2025", false}, // ponto no nome da pasta
+		{dir, dir, false},
+		{"relatorio.csv", "", true},
+		{"saida/Relatorio.PPTX", "", true},
+		{"r.parquet", "", true},
+		{file, "", true}, // arquivo existente sem extensão
+	}
+	for _, tt := range tests {
+		got, err := backfillBaseDir(tt.out)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("backfillBaseDir(%q) = %q, %v; want %q (err %v)", tt.out, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "--out") {
+			t.Errorf("backfillBaseDir(%q): error should name --out: %v", tt.out, err)
+		}
+	}
+}
+
+func TestBackfillPartialFailure(t *testing.T) {
+	db := sourceDB(t, "2025-10-05 10:00:00", "2025-11-05 10:00:00", "2025-12-05 10:00:00")
+	months, err := backfillMonths("2025-10", "2025-12", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := t.TempDir()
+	// Um arquivo no lugar da pasta de novembro: só esse mês falha.
+	if err := os.WriteFile(filepath.Join(base, "2025_11"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := backfillOptions{
+		Workers: 2,
+		BaseDir: base,
+		Reports: reportOptions{Export: exportOptions{Formats: []string{"csv"}, DBLoc: time.UTC}},
+	}
+	results := runBackfill(db, engineSQLite, months, opts)
+
+	for i, name := range []string{"2025_10/relatorio_2025_10.csv", "", "2025_12/relatorio_2025_12.csv"} {
+		r := results[i]
+		if name == "" {
+			if r.Err == nil || errorStage(r.Err, "") != stageWrite {
+				t.Errorf("2025-11: err = %v, want a write-stage error", r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.Rows != 1 {
+			t.Errorf("%s: rows = %d, err = %v", name, r.Rows, r.Err)
+		}
+		if _, err := os.Stat(filepath.Join(base, name)); err != nil {
+			t.Errorf("other months must still be written: %v", err)
+		}
+	}
+
+	err = backfillErr(results, printBackfillSummary(results))
+	var be *batchError
+	if !errors.As(err, &be) || be.Failed != 1 || be.Total != 3 || len(be.Errs) != 1 {
+		t.Fatalf("backfillErr = %#v, want 1 of 3 months failed", err)
+	}
+	if !strings.Contains(err.Error(), "1 of 3 months failed") {
+		t.Errorf("error = %q", err)
+	}
+	// O código de saída é o da etapa do mês que falhou, não o de alerta.
+	if got := exitCode(err); got != exitWrite {
+		t.Errorf("exitCode = %d, want %d (write)", got, exitWrite)
+	}
+	if err := backfillErr(results[:1], 0); err != nil {
+		t.Errorf("no failed month: err = %v", err)
+	}
+}
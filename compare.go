@@ -0,0 +1,489 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Testes de significância (--compare): o período atual contra outro período
+// ("previous" ou CSVs de base) ou cada andar contra o restante ("floors").
+//
+// Por pergunta saem dois testes:
+//   - duas proporções (z) sobre o % de respostas positivas, o número que
+//     aparece no resumo executivo; é ele que marca o slide;
+//   - qui-quadrado de homogeneidade sobre a distribuição inteira de respostas.
+//
+// Ambos vão para o CSV de estatísticas (--stats).
+//
+// No modo floors cada pergunta testa todos os andares de uma vez: com 10
+// andares a 5%, a chance de algum sair "significativo" por acaso passa de 40%.
+// Por isso o p de cada andar é ajustado por Holm dentro da pergunta (a nota
+// do slide), e é o p ajustado que decide.
+
+const defaultAlpha = 0.05
+
+type comparison struct {
+	Mode     string // "period" ou "floors"
+	Previous bool   // Mode period com base = período anterior (vem do banco)
+	Alpha    float64
+
+	// Mode period.
+	Baseline      *answerCounts
+	BaselineLabel string
+	CurrentLabel  string
+}
+
+// parseCompare interpreta --compare: previous, floors ou CSVs de base
+// (lista separada por vírgula e/ou glob, como --pptx-from), lidos no fuso loc.
+func parseCompare(spec string, alpha float64, dd *deduper, loc *time.Location) (*comparison, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	if alpha <= 0 || alpha >= 1 {
+		return nil, fmt.Errorf("invalid --alpha %g (use a value between 0 and 1, e.g. 0.05)", alpha)
+	}
+	switch strings.ToLower(spec) {
+	case "floors", "andares":
+		return &comparison{Mode: "floors", Alpha: alpha}, nil
+	case "previous", "anterior":
+		return &comparison{Mode: "period", Previous: true, Alpha: alpha}, nil
+	}
+	paths, err := expandCSVPaths(spec)
+	if err != nil {
+		return nil, fmt.Errorf("--compare: %w", err)
+	}
+	merged, err := readCSVs(paths, dd, loc)
+	if err != nil {
+		return nil, fmt.Errorf("--compare: %w", err)
+	}
+	if merged.First.IsZero() {
+		return nil, fmt.Errorf("--compare: %s has no Data - Criação, cannot tell its period", spec)
+	}
+	first, last := monthBounds(merged.First, merged.Last)
+	return &comparison{
+		Mode:          "period",
+		Alpha:         alpha,
+		Baseline:      merged.Counts,
+		BaselineLabel: msgs.periodShort(first, last),
+	}, nil
+}
+
+// previousPeriod: mesmo tamanho, imediatamente antes. Meses inteiros voltam
+// em meses (dezembro -> novembro), o resto em duração.
+func previousPeriod(start, end time.Time) (time.Time, time.Time) {
+	last := end.Add(-time.Nanosecond)
+	if monthAligned(start, last) {
+		months := (last.Year()-start.Year())*12 + int(last.Month()-start.Month()) + 1
+		return start.AddDate(0, -months, 0), start
+	}
+	return start.Add(-end.Sub(start)), start
+}
+
+// forPeriod devolve a comparação pronta para o período [start, end): com
+// "previous", exporta o período anterior para um CSV temporário (mesmas regras
+// de --replace e dedupe) e conta as respostas.
+func (c *comparison) forPeriod(ctx context.Context, db *sql.DB, engine string, start, end time.Time, opts exportOptions) (*comparison, error) {
+	if c == nil {
+		return nil, nil
+	}
+	out := *c
+	out.CurrentLabel = msgs.periodShort(start, end.Add(-time.Nanosecond))
+	if !c.Previous {
+		return &out, nil
+	}
+	if db == nil {
+		return nil, errors.New("--compare=previous needs the database (not available with --pptx-from)")
+	}
+	prevStart, prevEnd := previousPeriod(start, end)
+	dir, err := os.MkdirTemp("", "auto_relatorio_cmp")
+	if err != nil {
+		return nil, fmt.Errorf("compare: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts.Formats = []string{"csv"}
+	tmp := filepath.Join(dir, "base.csv")
+	if _, err := exportPeriod(ctx, db, engine, prevStart, prevEnd, tmp, opts); err != nil {
+		return nil, fmt.Errorf("compare: previous period: %w", err)
+	}
+	merged, err := readCSVs([]string{tmp}, nil, opts.DBLoc)
+	if err != nil {
+		return nil, fmt.Errorf("compare: previous period: %w", err)
+	}
+	out.Baseline = merged.Counts
+	out.BaselineLabel = msgs.periodShort(prevStart, prevEnd.Add(-time.Nanosecond))
+	return &out, nil
+}
+
+// withCurrent preenche o rótulo do período atual quando ele só é conhecido
+// pelas datas lidas (--pptx-from).
+func (c *comparison) withCurrent(first, last time.Time) *comparison {
+	if c == nil || c.CurrentLabel != "" {
+		return c
+	}
+	out := *c
+	out.CurrentLabel = msgs.periodShort(first, last)
+	return &out
+}
+
+// compareResult é uma linha do CSV de estatísticas.
+type compareResult struct {
+	Comparison string // "Dezembro/2025 vs Novembro/2025", "andar:3 vs demais"
+	SegA, SegB string
+	Question   questionCol
+
+	// Duas proporções (% positivas).
+	NA, NB   int
+	PA, PB   float64 // 0..100
+	Z, PProp float64
+	PropOK   bool
+
+	// Qui-quadrado sobre a distribuição de respostas.
+	Chi2   float64
+	DF     int
+	PChi2  float64
+	ChiOK  bool
+	Sparse bool // alguma frequência esperada < 5: qui-quadrado pouco confiável
+
+	// PAdj é o p do teste que decide (testP) ajustado por Holm entre os Tests
+	// testes da mesma pergunta; no modo period, Tests = 1 e PAdj = p.
+	PAdj  float64
+	Tests int
+}
+
+// testP é o p do teste que decide: o de proporções; sem base positiva
+// (respostas sem código conhecido), o qui-quadrado.
+func (r compareResult) testP() (float64, bool) {
+	switch {
+	case r.PropOK:
+		return r.PProp, true
+	case r.ChiOK:
+		return r.PChi2, true
+	}
+	return 1, false
+}
+
+func (r compareResult) significant(alpha float64) bool {
+	_, ok := r.testP()
+	return ok && r.PAdj < alpha
+}
+
+func compareCounts(label, segA, segB string, qc questionCol, a, b map[string]int) compareResult {
+	r := compareResult{Comparison: label, SegA: segA, SegB: segB, Question: qc}
+	posA, baseA := positiveCounts(a)
+	posB, baseB := positiveCounts(b)
+	r.NA, r.NB = baseA, baseB
+	if baseA > 0 && baseB > 0 {
+		r.PA = float64(posA) / float64(baseA) * 100
+		r.PB = float64(posB) / float64(baseB) * 100
+		r.Z, r.PProp, r.PropOK = twoProportion(posA, baseA, posB, baseB)
+	}
+	r.Chi2, r.DF, r.PChi2, r.Sparse, r.ChiOK = chiSquare(a, b)
+	r.PAdj, _ = r.testP()
+	r.Tests = 1
+	return r
+}
+
+// holmAdjust ajusta PAdj por Holm-Bonferroni dentro de cada pergunta: o
+// k-ésimo menor p de m testes vira (m-k+1)·p, sem nunca ficar menor que o
+// ajustado anterior.
+func holmAdjust(results []compareResult) {
+	byQuestion := map[int][]int{}
+	for i, r := range results {
+		if _, ok := r.testP(); ok {
+			byQuestion[r.Question.Number] = append(byQuestion[r.Question.Number], i)
+		}
+	}
+	for _, idx := range byQuestion {
+		sort.SliceStable(idx, func(a, b int) bool { return results[idx[a]].PAdj < results[idx[b]].PAdj })
+		m, prev := len(idx), 0.0
+		for k, i := range idx {
+			prev = math.Max(prev, math.Min(1, float64(m-k)*results[i].PAdj))
+			results[i].PAdj = prev
+			results[i].Tests = m
+		}
+	}
+}
+
+// twoProportion: teste z bilateral com proporção combinada.
+func twoProportion(k1, n1, k2, n2 int) (z, p float64, ok bool) {
+	if n1 == 0 || n2 == 0 {
+		return 0, 1, false
+	}
+	p1 := float64(k1) / float64(n1)
+	p2 := float64(k2) / float64(n2)
+	pooled := float64(k1+k2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		// Os dois grupos 0% ou 100%: nenhuma diferença a testar.
+		return 0, 1, true
+	}
+	z = (p1 - p2) / se
+	return z, math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
+
+// chiSquare: tabela respostas x 2 grupos.
+func chiSquare(a, b map[string]int) (chi2 float64, df int, p float64, sparse, ok bool) {
+	keys := map[string]bool{}
+	na, nb := 0, 0
+	for k, c := range a {
+		keys[k] = true
+		na += c
+	}
+	for k, c := range b {
+		keys[k] = true
+		nb += c
+	}
+	if na == 0 || nb == 0 || len(keys) < 2 {
+		return 0, 0, 1, false, false
+	}
+	n := float64(na + nb)
+	for k := range keys {
+		row := float64(a[k] + b[k])
+		for _, cell := range []struct {
+			obs, col int
+		}{{a[k], na}, {b[k], nb}} {
+			exp := row * float64(cell.col) / n
+			if exp < 5 {
+				sparse = true
+			}
+			d := float64(cell.obs) - exp
+			chi2 += d * d / exp
+		}
+	}
+	df = len(keys) - 1
+	return chi2, df, chiSquareSF(chi2, df), sparse, true
+}
+
+// chiSquareSF: P(X >= x) para qui-quadrado com df graus de liberdade.
+func chiSquareSF(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, x/2)
+}
+
+// gammaQ: função gama incompleta superior regularizada Q(a, x)
+// (série para x < a+1, fração contínua no resto; Numerical Recipes 6.2).
+func gammaQ(a, x float64) float64 {
+	const (
+		eps   = 1e-14
+		itmax = 500
+		fpmin = 1e-300
+	)
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		ap, sum := a, 1/a
+		del := sum
+		for i := 0; i < itmax; i++ {
+			ap++
+			del *= x / ap
+			sum += del
+			if math.Abs(del) < math.Abs(sum)*eps {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+	}
+	b := x + 1 - a
+	c := 1 / fpmin
+	d := 1 / b
+	h := d
+	for i := 1; i <= itmax; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = b + an/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+// results roda os testes para todas as perguntas de ac.
+func (c *comparison) results(ac *answerCounts) []compareResult {
+	var out []compareResult
+	switch c.Mode {
+	case "period":
+		if c.Baseline == nil {
+			return nil
+		}
+		label := c.CurrentLabel + " vs " + c.BaselineLabel
+		for i, qc := range ac.Questions {
+			j := questionIndex(c.Baseline, qc.Number)
+			if j < 0 {
+				continue
+			}
+			out = append(out, compareCounts(label, "geral", "geral", qc, ac.Total[i], c.Baseline.Total[j]))
+		}
+	case "floors":
+		floors := make([]string, 0, len(ac.ByFloor))
+		for f := range ac.ByFloor {
+			floors = append(floors, f)
+		}
+		sort.Strings(floors)
+		for _, f := range floors {
+			seg := floorSegment(f)
+			for i, qc := range ac.Questions {
+				own := ac.ByFloor[f][i]
+				rest := map[string]int{}
+				for k, v := range ac.Total[i] {
+					if d := v - own[k]; d > 0 {
+						rest[k] = d
+					}
+				}
+				out = append(out, compareCounts(seg+" vs demais", seg, "demais", qc, own, rest))
+			}
+		}
+		holmAdjust(out)
+	}
+	return out
+}
+
+func questionIndex(ac *answerCounts, number int) int {
+	for i, qc := range ac.Questions {
+		if qc.Number == number {
+			return i
+		}
+	}
+	return -1
+}
+
+// slideNote monta a nota do slide da pergunta number a partir dos resultados.
+func (c *comparison) slideNote(results []compareResult, number int) (string, bool) {
+	cm := msgs.Compare
+	switch c.Mode {
+	case "period":
+		for _, r := range results {
+			if r.Question.Number != number {
+				continue
+			}
+			if !r.PropOK {
+				return fmt.Sprintf(cm.NoData, c.BaselineLabel), false
+			}
+			sig := r.significant(c.Alpha)
+			word := cm.NotSignificant
+			if sig {
+				word = cm.Significant
+			}
+			return fmt.Sprintf(cm.Vs, c.BaselineLabel, r.PB, r.PA, r.PA-r.PB, r.PProp, word), sig
+		}
+		return fmt.Sprintf(cm.NoData, c.BaselineLabel), false
+	case "floors":
+		var diff []string
+		tests := 0
+		for _, r := range results {
+			if r.Question.Number != number {
+				continue
+			}
+			if _, ok := r.testP(); ok {
+				tests = r.Tests
+			}
+			if r.significant(c.Alpha) {
+				diff = append(diff, fmt.Sprintf("%s (p = %.3f)", strings.TrimPrefix(r.SegA, "andar:"), r.PAdj))
+			}
+		}
+		if len(diff) == 0 {
+			return fmt.Sprintf(cm.FloorsNone, c.Alpha, tests), false
+		}
+		return fmt.Sprintf(cm.Floors, c.Alpha, tests, strings.Join(diff, ", ")), true
+	}
+	return "", false
+}
+
+var statsHeader = []string{
+	"comparacao", "segmento_a", "segmento_b", "questao", "titulo",
+	"n_a", "n_b", "positivas_a", "positivas_b", "diferenca_pp", "z", "p_proporcao",
+	"qui2", "gl", "p_qui2", "p_ajustado", "testes", "poucos_dados", "significativo",
+}
+
+// defaultStatsPath: relatorio_2025_12.csv -> relatorio_2025_12_stats.csv
+func defaultStatsPath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "_stats.csv"
+}
+
+func writeStatsCSV(path string, results []compareResult, alpha float64) error {
+	num := func(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
+	flag := func(b bool) string {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rec := []string{r.Comparison, r.SegA, r.SegB, strconv.Itoa(r.Question.Number), r.Question.Title,
+			strconv.Itoa(r.NA), strconv.Itoa(r.NB), "", "", "", "", "", "", "", "", "", "", flag(r.Sparse), flag(r.significant(alpha))}
+		if r.PropOK {
+			rec[7], rec[8], rec[9] = num(r.PA, 1), num(r.PB, 1), num(r.PA-r.PB, 1)
+			rec[10], rec[11] = num(r.Z, 3), num(r.PProp, 4)
+		}
+		if r.ChiOK {
+			rec[12], rec[13], rec[14] = num(r.Chi2, 3), strconv.Itoa(r.DF), num(r.PChi2, 4)
+		}
+		if _, ok := r.testP(); ok {
+			rec[15], rec[16] = num(r.PAdj, 4), strconv.Itoa(r.Tests)
+		}
+		rows = append(rows, rec)
+	}
+	return writeDialectCSV(path, statsHeader, rows)
+}
+
+// runStats grava o CSV de estatísticas das respostas do período.
+func runStats(statsFlag string, in *periodAnswers, opts pptxOptions) error {
+	statsFlag = strings.TrimSpace(statsFlag)
+	if statsFlag == "" || opts.Compare == nil {
+		return nil
+	}
+	path := statsFlag
+	if strings.EqualFold(statsFlag, "auto") {
+		if len(in.Paths) != 1 {
+			return fmt.Errorf("--stats=auto with %d CSVs: pass a file path instead", len(in.Paths))
+		}
+		path = defaultStatsPath(in.Paths[0])
+	}
+	merged, err := in.load()
+	if err != nil {
+		return err
+	}
+	cmp := opts.Compare
+	if !merged.First.IsZero() {
+		cmp = cmp.withCurrent(monthBounds(merged.First, merged.Last))
+	}
+	results := cmp.results(merged.Counts)
+	if err := writeStatsCSV(path, results, cmp.Alpha); err != nil {
+		return err
+	}
+	sig := 0
+	for _, r := range results {
+		if r.significant(cmp.Alpha) {
+			sig++
+		}
+	}
+	holm := ""
+	if cmp.Mode == "floors" {
+		holm = ", p ajustado por Holm em cada pergunta"
+	}
+	fmt.Printf("OK: %d comparações (%d significativas, alfa %g%s) gravadas em %s\n", len(results), sig, cmp.Alpha, holm, path)
+	return nil
+}
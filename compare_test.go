@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func near(a, b, tol float64) bool { return math.Abs(a-b) <= tol }
+
+func TestChiSquareSF(t *testing.T) {
+	tests := []struct {
+		x    float64
+		df   int
+		want float64
+	}{
+		{3.841459, 1, 0.05},  // valor crítico clássico, fração contínua
+		{0.454936, 1, 0.50},  // mediana, série
+		{5.991465, 2, 0.05},  // df=2: exp(-x/2)
+		{11.070498, 5, 0.05}, // a não inteiro (2.5)
+		{6.634897, 1, 0.01},
+		{0, 3, 1},
+		{-1, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := chiSquareSF(tt.x, tt.df); !near(got, tt.want, 1e-6) {
+			t.Errorf("chiSquareSF(%g, %d) = %.8f, want %g", tt.x, tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestGammaQ(t *testing.T) {
+	// Q(1, x) = e^-x nos dois ramos (série para x < 2, fração contínua no resto).
+	for _, x := range []float64{0.1, 0.5, 1.5, 2, 5, 30} {
+		if got, want := gammaQ(1, x), math.Exp(-x); !near(got, want, 1e-12) {
+			t.Errorf("gammaQ(1, %g) = %g, want %g", x, got, want)
+		}
+	}
+	// Q(1/2, x) = erfc(√x).
+	for _, x := range []float64{0.2, 1, 4} {
+		if got, want := gammaQ(0.5, x), math.Erfc(math.Sqrt(x)); !near(got, want, 1e-12) {
+			t.Errorf("gammaQ(0.5, %g) = %g, want %g", x, got, want)
+		}
+	}
+}
+
+func TestTwoProportion(t *testing.T) {
+	tests := []struct {
+		name           string
+		k1, n1, k2, n2 int
+		wantZ, wantP   float64
+		wantOK         bool
+	}{
+		{"45% vs 30%", 45, 100, 30, 100, 2.190890, 0.028460, true},
+		{"equal", 30, 100, 30, 100, 0, 1, true},
+		{"both 100%", 50, 50, 20, 20, 0, 1, true},
+		{"both 0%", 0, 50, 0, 20, 0, 1, true},
+		{"empty group", 0, 0, 10, 20, 0, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z, p, ok := twoProportion(tt.k1, tt.n1, tt.k2, tt.n2)
+			if ok != tt.wantOK || !near(z, tt.wantZ, 1e-5) || !near(p, tt.wantP, 1e-5) {
+				t.Errorf("twoProportion = (%.6f, %.6f, %v), want (%g, %g, %v)", z, p, ok, tt.wantZ, tt.wantP, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestChiSquare(t *testing.T) {
+	// 2x2: qui-quadrado = z² do teste de proporções, mesmo p.
+	chi2, df, p, sparse, ok := chiSquare(map[string]int{"Sim": 45, "Não": 55}, map[string]int{"Sim": 30, "Não": 70})
+	if !ok || df != 1 || sparse || !near(chi2, 4.8, 1e-9) || !near(p, 0.028460, 1e-5) {
+		t.Errorf("2x2 = (%g, %d, %g, %v, %v)", chi2, df, p, sparse, ok)
+	}
+
+	// Resposta que só um grupo deu: frequência esperada < 5.
+	_, df, _, sparse, ok = chiSquare(map[string]int{"Boa": 20, "Ruim": 2}, map[string]int{"Boa": 25})
+	if !ok || df != 1 || !sparse {
+		t.Errorf("sparse table: df=%d sparse=%v ok=%v", df, sparse, ok)
+	}
+
+	for name, tt := range map[string][2]map[string]int{
+		"empty group":       {{"Boa": 10}, {}},
+		"zero counts":       {{"Boa": 0, "Ruim": 0}, {"Boa": 3}},
+		"single answer df0": {{"Sim": 10}, {"Sim": 8}},
+	} {
+		if _, df, p, _, ok := chiSquare(tt[0], tt[1]); ok || df != 0 || p != 1 {
+			t.Errorf("%s: df=%d p=%g ok=%v, want not testable", name, df, p, ok)
+		}
+	}
+}
+
+func TestHolmAdjust(t *testing.T) {
+	q1, q2 := questionCol{Number: 1}, questionCol{Number: 2}
+	res := []compareResult{
+		{Question: q1, PropOK: true, PProp: 0.01},
+		{Question: q1, PropOK: true, PProp: 0.04},
+		{Question: q1, PropOK: true, PProp: 0.03},
+		{Question: q1, PropOK: true, PProp: 0.005},
+		{Question: q1}, // sem teste: fora da família
+		{Question: q2, PropOK: true, PProp: 0.03},
+	}
+	for i := range res {
+		res[i].PAdj, _ = res[i].testP()
+	}
+	holmAdjust(res)
+	want := []float64{0.03, 0.06, 0.06, 0.02, 1, 0.03}
+	wantTests := []int{4, 4, 4, 4, 0, 1}
+	for i, r := range res {
+		if !near(r.PAdj, want[i], 1e-12) || r.Tests != wantTests[i] {
+			t.Errorf("result %d: PAdj=%g Tests=%d, want %g, %d", i, r.PAdj, r.Tests, want[i], wantTests[i])
+		}
+	}
+	if res[1].significant(0.05) || !res[0].significant(0.05) || res[4].significant(0.05) {
+		t.Error("significant must use the adjusted p")
+	}
+}
+
+func TestCompareFloorsCorrected(t *testing.T) {
+	// Dez andares iguais (80% Boa) e o 3 bem abaixo: só ele sai na nota,
+	// com o p ajustado pelos 11 testes.
+	ac := &answerCounts{
+		Questions: []questionCol{{Number: 1, Title: "RECEPÇÃO"}},
+		Total:     []map[string]int{{}},
+		ByFloor:   map[string][]map[string]int{},
+	}
+	add := func(floor string, boa, ruim int) {
+		ac.ByFloor[floor] = []map[string]int{{"Boa": boa, "Ruim": ruim}}
+		ac.Total[0]["Boa"] += boa
+		ac.Total[0]["Ruim"] += ruim
+	}
+	for _, f := range []string{"1", "2", "4", "5", "6", "7", "8", "9", "10", "11"} {
+		add(f, 40, 10)
+	}
+	add("3", 20, 30)
+
+	c := &comparison{Mode: "floors", Alpha: 0.05}
+	results := c.results(ac)
+	if len(results) != 11 {
+		t.Fatalf("%d results, want 11", len(results))
+	}
+	for _, r := range results {
+		if r.Tests != 11 || r.PAdj < r.PProp {
+			t.Errorf("%s: Tests=%d PAdj=%g < p=%g", r.SegA, r.Tests, r.PAdj, r.PProp)
+		}
+	}
+	note, alert := c.slideNote(results, 1)
+	if !alert || !strings.Contains(note, "Holm entre 11 andares") || !strings.Contains(note, "): 3 (p = ") || strings.Count(note, "(p = ") != 1 {
+		t.Errorf("note = %q (alert %v)", note, alert)
+	}
+}
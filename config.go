@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Arquivo de configuração com perfis (--config, --profile), para não trocar
+// .env à mão entre o hospital, o hospital-dia e a homologação:
+//
+//	default: principal
+//	profiles:
+//	  principal:
+//	    db: {driver: mysql, host: db.hospital.local, name: hospital, user: relatorio, password_env: MYSQL_PASS_PRINCIPAL}
+//	    out: D:/relatorios/principal
+//	    replace: true
+//	    pptx: auto
+//	    webhook: https://...
+//
+// Cada chave do perfil é o nome de uma flag (sem "--"); "db" monta o --dsn a
+// partir das partes. Flag passada na linha de comando vence o perfil.
+
+const defaultConfigName = "auto_relatorio.yaml"
+
+type configFile struct {
+	Default  string                          `yaml:"default"`
+	Profiles map[string]map[string]yaml.Node `yaml:"profiles"`
+}
+
+// profileDB são as partes do DSN; senha de preferência por variável de ambiente
+// ou arquivo.
+type profileDB struct {
+	Driver       string   `yaml:"driver"`
+	DSN          string   `yaml:"dsn"`
+	Host         string   `yaml:"host"`
+	Port         int      `yaml:"port"`
+	Name         string   `yaml:"name"`
+	User         string   `yaml:"user"`
+	Password     string   `yaml:"password"`
+	PasswordEnv  string   `yaml:"password_env"`
+	PasswordFile string   `yaml:"password_file"`
+	DSNFile      string   `yaml:"dsn_file"`
+	SSLMode      string   `yaml:"sslmode"`
+	Path         string   `yaml:"path"` // sqlite
+	TLS          mysqlTLS `yaml:"tls"`  // mysql
+}
+
+// Flags que não fazem sentido dentro de um perfil.
+var profileReserved = map[string]bool{"config": true, "profile": true, "dsn": true, "dsn-file": true, "driver": true, "units": true}
+
+// findConfig: --config; senão auto_relatorio.yaml (ou .yml) na pasta atual ou
+// ao lado do executável. "" se não houver.
+func findConfig(flagPath string) (string, error) {
+	if p := strings.TrimSpace(flagPath); p != "" {
+		if _, err := os.Stat(p); err != nil {
+			return "", fmt.Errorf("--config: %w", err)
+		}
+		return p, nil
+	}
+	dirs := []string{"."}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	for _, dir := range dirs {
+		for _, name := range []string{defaultConfigName, strings.TrimSuffix(defaultConfigName, ".yaml") + ".yml"} {
+			p := filepath.Join(dir, name)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// applyProfile aplica o perfil às flags que não vieram na linha de comando e
+// devolve o arquivo e o nome do perfil usados ("" se nenhum).
+func applyProfile(fs *flag.FlagSet, configPath, profile string) (usedPath, usedProfile string, err error) {
+	path, cfg, err := readConfig(configPath)
+	if err != nil {
+		return "", "", err
+	}
+	profile = strings.TrimSpace(profile)
+	if path == "" {
+		if profile != "" {
+			return "", "", fmt.Errorf("--profile %s: no config file (use --config or create %s)", profile, defaultConfigName)
+		}
+		return "", "", nil
+	}
+	if profile == "" {
+		profile = cfg.Default
+	}
+	if profile == "" {
+		return "", "", nil
+	}
+	values, err := cfg.profile(path, profile)
+	if err != nil {
+		return "", "", err
+	}
+
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		node := values[key]
+		if key == "db" {
+			if explicit["dsn"] || explicit["dsn-file"] {
+				continue
+			}
+			var db profileDB
+			if err := node.Decode(&db); err != nil {
+				return "", "", fmt.Errorf("profile %s: db: %w", profile, err)
+			}
+			engine, dsn, err := db.resolve(profile)
+			if err != nil {
+				return "", "", fmt.Errorf("profile %s: db: %w", profile, err)
+			}
+			if !explicit["driver"] {
+				_ = fs.Set("driver", engine)
+			}
+			_ = fs.Set("dsn", dsn)
+			continue
+		}
+		if profileReserved[key] || fs.Lookup(key) == nil {
+			return "", "", fmt.Errorf("profile %s: unknown option %q (use flag names without --, or db)", profile, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		v, err := nodeFlagValue(node)
+		if err != nil {
+			return "", "", fmt.Errorf("profile %s: %s: %w", profile, key, err)
+		}
+		if err := fs.Set(key, v); err != nil {
+			return "", "", fmt.Errorf("profile %s: %s: %w", profile, key, err)
+		}
+	}
+	return path, profile, nil
+}
+
+// readConfig lê o arquivo achado por findConfig; path "" se não houver.
+func readConfig(configPath string) (string, configFile, error) {
+	var cfg configFile
+	path, err := findConfig(configPath)
+	if err != nil || path == "" {
+		return "", cfg, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", cfg, fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return "", cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return path, cfg, nil
+}
+
+func (c configFile) profile(path, name string) (map[string]yaml.Node, error) {
+	values, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found in %s (profiles: %s)", name, path, strings.Join(names, ", "))
+	}
+	return values, nil
+}
+
+// unitSource é uma unidade do --units: o banco vem do "db" do perfil de mesmo
+// nome; o resto do perfil não se aplica (as flags valem para todas).
+type unitSource struct {
+	Name   string
+	Engine string
+	DSN    string
+	DBTZ   string // "db-tz" do perfil; vazio = --db-tz
+}
+
+// Nome da unidade vai na frente dos arquivos: nada de espaço ou barra.
+var unitNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// loadUnits resolve a lista "principal,hospital_dia" do --units.
+func loadUnits(configPath, list string) ([]unitSource, error) {
+	path, cfg, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("--units needs a config file with one profile per unit (use --config or create %s)", defaultConfigName)
+	}
+	var out []unitSource
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !unitNameRe.MatchString(name) {
+			return nil, fmt.Errorf("--units: %q is not usable as a file prefix (letters, digits, _ . - only)", name)
+		}
+		values, err := cfg.profile(path, name)
+		if err != nil {
+			return nil, fmt.Errorf("--units: %w", err)
+		}
+		node, ok := values["db"]
+		if !ok {
+			return nil, fmt.Errorf("--units: profile %s has no db section", name)
+		}
+		var db profileDB
+		if err := node.Decode(&db); err != nil {
+			return nil, fmt.Errorf("profile %s: db: %w", name, err)
+		}
+		u := unitSource{Name: name}
+		if u.Engine, u.DSN, err = db.resolve(name); err != nil {
+			return nil, fmt.Errorf("profile %s: db: %w", name, err)
+		}
+		if tz, ok := values["db-tz"]; ok {
+			if u.DBTZ, err = nodeFlagValue(tz); err != nil {
+				return nil, fmt.Errorf("profile %s: db-tz: %w", name, err)
+			}
+		}
+		out = append(out, u)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("--units is empty")
+	}
+	return out, nil
+}
+
+// nodeFlagValue converte o valor do YAML no texto da flag; listas viram
+// "a,b,c" (--format, --targets, --compare...).
+func nodeFlagValue(n yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value, nil
+	case yaml.SequenceNode:
+		parts := make([]string, 0, len(n.Content))
+		for _, c := range n.Content {
+			if c.Kind != yaml.ScalarNode {
+				return "", errors.New("lists must contain plain values")
+			}
+			parts = append(parts, c.Value)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", errors.New("expected a value or a list")
+}
+
+// resolve monta (engine, dsn) como o resolveDSN faz com as variáveis do .env.
+// O TLS do perfil fica registrado no driver como auto_relatorio_<perfil>.
+func (d profileDB) resolve(profile string) (string, string, error) {
+	engine, dsn, err := d.dsn()
+	if err != nil {
+		return "", "", err
+	}
+	if d.TLS.enabled() {
+		if engine != engineMySQL {
+			return "", "", errors.New("tls is for mysql; for postgres use sslmode/sslrootcert")
+		}
+		if dsn, err = applyMySQLTLS(dsn, d.TLS, tlsConfigName+"_"+profile); err != nil {
+			return "", "", err
+		}
+	}
+	return engine, dsn, nil
+}
+
+func (d profileDB) dsn() (string, string, error) {
+	if d.DSNFile != "" {
+		dsn, err := readSecretFile(d.DSNFile)
+		if err != nil {
+			return "", "", fmt.Errorf("dsn_file: %w", err)
+		}
+		d.DSN = dsn
+	}
+	engine, err := detectEngine(d.Driver, d.DSN)
+	if err != nil {
+		return "", "", err
+	}
+	if d.DSN != "" {
+		return engine, d.DSN, nil
+	}
+	pass := d.Password
+	switch {
+	case d.PasswordEnv != "":
+		pass = os.Getenv(d.PasswordEnv)
+		if pass == "" {
+			return "", "", fmt.Errorf("password_env %s is empty", d.PasswordEnv)
+		}
+	case d.PasswordFile != "":
+		if pass, err = readSecretFile(d.PasswordFile); err != nil {
+			return "", "", fmt.Errorf("password_file: %w", err)
+		}
+	}
+	port := ""
+	if d.Port > 0 {
+		port = strconv.Itoa(d.Port)
+	}
+	switch engine {
+	case engineSQLite:
+		if d.Path == "" {
+			return "", "", errors.New("sqlite needs path")
+		}
+		return engine, d.Path, nil
+	case enginePostgres:
+		if d.Name == "" || d.User == "" {
+			return "", "", errors.New("postgres needs name and user")
+		}
+		return engine, postgresDSN(firstNonEmpty(d.Host, "127.0.0.1"), firstNonEmpty(port, "5432"), d.Name, d.User, pass, firstNonEmpty(d.SSLMode, "prefer")), nil
+	}
+	if d.Name == "" || d.User == "" {
+		return "", "", errors.New("mysql needs name and user")
+	}
+	return engine, mysqlDSN(firstNonEmpty(d.Host, "127.0.0.1"), firstNonEmpty(port, "3306"), d.Name, d.User, pass), nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// csvLayout diz em que coluna está cada campo de um CSV lido por --pptx-from.
+// As colunas são achadas pelo nome do cabeçalho, não pela posição, então um CSV
+// reordenado no Excel ou exportado pelo phpMyAdmin também serve.
+// -1 significa "coluna ausente".
+type csvLayout struct {
+	Floor     int
+	Patient   int
+	Created   int
+	Registrar int
+	Unit      int           // só no CSV consolidado do --units
+	Questions []questionCol // só as perguntas de gráfico presentes no arquivo
+}
+
+// strictColumns (--strict-columns): uma pergunta de gráfico ausente no CSV
+// também é erro, em vez de só ficar sem slide.
+var strictColumns bool
+
+const (
+	colFloor     = "num_andar"
+	colPatient   = "nome_paciente"
+	colCreated   = "created"
+	colRegistrar = "cadastrador"
+	colUnit      = "unidade"
+)
+
+// csvColumnAliases mapeia nome normalizado -> coluna lógica ("num_andar",
+// "questao7", ...). Aceita o nome da coluna no banco, o nome do JSONL/Parquet e
+// os títulos de todos os idiomas de --lang.
+func csvColumnAliases() map[string]string {
+	aliases := map[string]string{}
+	add := func(name, col string) {
+		aliases[normalizeColumnName(name)] = col
+	}
+
+	// Layout do record: 0 andar, 1 paciente, 2..21 questões, 22 created, 23 cadastrador.
+	logical := make([]string, 24)
+	logical[0], logical[1], logical[22], logical[23] = colFloor, colPatient, colCreated, colRegistrar
+	for n := 1; n <= 20; n++ {
+		logical[1+n] = "questao" + strconv.Itoa(n)
+	}
+
+	for _, m := range catalogs {
+		for i, title := range m.Header {
+			add(title, logical[i])
+		}
+	}
+	for _, m := range catalogs {
+		add(m.Unit, colUnit)
+	}
+	for _, col := range logical {
+		add(col, col)
+	}
+	add("andar", colFloor)
+	add("paciente", colPatient)
+	add("data", colCreated)
+	return aliases
+}
+
+// normalizeColumnName ignora caixa, acentos e espaços repetidos,
+// para "Atendimento  Medico" casar com "ATENDIMENTO MÉDICO".
+func normalizeColumnName(s string) string {
+	s = strings.TrimPrefix(s, "\ufeff")
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if folded, _, err := transform.String(t, s); err == nil {
+		s = folded
+	}
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func mapCSVHeader(headerRow []string) (csvLayout, error) {
+	aliases := csvColumnAliases()
+
+	found := map[string]int{}
+	for i, name := range headerRow {
+		col, ok := aliases[normalizeColumnName(name)]
+		if !ok {
+			continue // colunas extras são ignoradas
+		}
+		if prev, dup := found[col]; dup {
+			return csvLayout{}, fmt.Errorf("csv header: columns %d (%q) and %d (%q) are both %s", prev+1, strings.TrimSpace(headerRow[prev]), i+1, strings.TrimSpace(name), col)
+		}
+		found[col] = i
+	}
+
+	idx := func(col string) int {
+		if i, ok := found[col]; ok {
+			return i
+		}
+		return -1
+	}
+	layout := csvLayout{
+		Floor:     idx(colFloor),
+		Patient:   idx(colPatient),
+		Created:   idx(colCreated),
+		Registrar: idx(colRegistrar),
+		Unit:      idx(colUnit),
+	}
+	for _, qc := range questionColumns() {
+		if i, ok := found["questao"+strconv.Itoa(qc.Number)]; ok {
+			qc.Index = i
+			layout.Questions = append(layout.Questions, qc)
+		}
+	}
+	if len(layout.Questions) == 0 {
+		return csvLayout{}, fmt.Errorf("csv header has no question columns (expected names like questao1 or %q); got: %s", msgs.Header[2], strings.Join(headerRow, " | "))
+	}
+	// Sem data não há dedupe, período nem mês; sem andar, o mapa de calor, a
+	// comparação por andar e o --publish-floors sairiam vazios sem aviso.
+	for _, req := range []struct {
+		i    int
+		col  string
+		name string
+	}{
+		{layout.Created, colCreated, msgs.Header[22]},
+		{layout.Floor, colFloor, msgs.Header[0]},
+	} {
+		if req.i < 0 {
+			return csvLayout{}, fmt.Errorf("csv header has no %s column (expected %s or %q); got: %s", req.col, req.col, req.name, strings.Join(headerRow, " | "))
+		}
+	}
+	if missing := layout.missingQuestions(); strictColumns && len(missing) > 0 {
+		return csvLayout{}, fmt.Errorf("csv header is missing %s (--strict-columns)", strings.Join(missing, ", "))
+	}
+	return layout, nil
+}
+
+// missingQuestions lista as perguntas de gráfico que não estão no CSV.
+func (l csvLayout) missingQuestions() []string {
+	have := map[int]bool{}
+	for _, qc := range l.Questions {
+		have[qc.Number] = true
+	}
+	var out []string
+	for _, qc := range questionColumns() {
+		if !have[qc.Number] {
+			out = append(out, "questao"+strconv.Itoa(qc.Number))
+		}
+	}
+	return out
+}
+
+// field devolve a coluna i da linha, ou "" se ausente/curta.
+func (l csvLayout) field(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
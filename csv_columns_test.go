@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMapCSVHeader(t *testing.T) {
+	full := append([]string(nil), msgs.Header...)
+	full[0] = "\ufeff" + full[0]
+	without := func(cols ...int) []string {
+		var out []string
+		for i, h := range msgs.Header {
+			skip := false
+			for _, c := range cols {
+				skip = skip || c == i
+			}
+			if !skip {
+				out = append(out, h)
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		header  []string
+		strict  bool
+		wantErr string
+	}{
+		{"exporter header", full, true, ""},
+		{"database names, reordered", []string{"created", "questao1", "num_andar", "extra"}, false, ""},
+		{"aliases", []string{"Data", "Andar", "Paciente", "ATENDIMENTO  MEDICO"}, false, ""},
+		{"no created column", without(22), false, "no created column"},
+		{"no floor column", without(0), false, "no num_andar column"},
+		{"missing questions tolerated", []string{"created", "num_andar", "questao1"}, false, ""},
+		{"missing questions strict", []string{"created", "num_andar", "questao1"}, true, "--strict-columns"},
+		{"no questions", []string{"created", "num_andar"}, false, "no question columns"},
+		{"duplicate column", []string{"created", "num_andar", "andar", "questao1"}, false, "are both num_andar"},
+	}
+	defer func(prev bool) { strictColumns = prev }(strictColumns)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strictColumns = tt.strict
+			layout, err := mapCSVHeader(tt.header)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if layout.Created < 0 || layout.Floor < 0 || len(layout.Questions) == 0 {
+					t.Errorf("layout = %+v", layout)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
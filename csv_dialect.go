@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// createdLayout é o formato interno de "Data - Criação" no record.
+// O formato que vai para o arquivo é o do dialeto (--csv-date-format).
+const createdLayout = "2006-01-02 15:04:05"
+
+// csvDialect descreve como o CSV é escrito (export) e lido (--pptx-from).
+type csvDialect struct {
+	Comma      rune
+	Quote      string // minimal | all | none
+	CRLF       bool
+	DateLayout string // layout Go
+	Decimal    string // "." ou ","
+	Encoding   string // utf-8 | windows-1252
+	BOM        bool
+}
+
+// dialect é o dialeto ativo (definido por flags em main).
+var dialect = defaultDialect()
+
+func defaultDialect() csvDialect {
+	return csvDialect{
+		Comma:      ';', // padrão comum pt-BR/Excel
+		Quote:      "minimal",
+		CRLF:       false,
+		DateLayout: createdLayout,
+		Decimal:    ".",
+		Encoding:   "utf-8",
+		BOM:        true,
+	}
+}
+
+func newCSVDialect(delim, quote, eol, dateFormat, decimal, encoding string, bom bool) (csvDialect, error) {
+	d := defaultDialect()
+
+	comma, err := parseDelimiter(delim)
+	if err != nil {
+		return d, err
+	}
+	d.Comma = comma
+
+	switch q := strings.ToLower(strings.TrimSpace(quote)); q {
+	case "", "minimal":
+		d.Quote = "minimal"
+	case "all", "none":
+		d.Quote = q
+	default:
+		return d, fmt.Errorf("invalid --csv-quote %q (use minimal, all or none)", quote)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(eol)) {
+	case "", "lf":
+		d.CRLF = false
+	case "crlf":
+		d.CRLF = true
+	default:
+		return d, fmt.Errorf("invalid --csv-eol %q (use lf or crlf)", eol)
+	}
+
+	if f := strings.TrimSpace(dateFormat); f != "" {
+		d.DateLayout = toGoLayout(f)
+		if err := checkDateLayout(d.DateLayout); err != nil {
+			return d, fmt.Errorf("invalid --csv-date-format %q: %w", dateFormat, err)
+		}
+	}
+
+	switch decimal {
+	case "", ".":
+		d.Decimal = "."
+	case ",":
+		d.Decimal = ","
+	default:
+		return d, fmt.Errorf("invalid --csv-decimal %q (use . or ,)", decimal)
+	}
+	if d.Decimal == "," && d.Comma == ',' {
+		return d, errors.New("--csv-decimal=, cannot be used with --csv-delimiter=,")
+	}
+
+	switch e := strings.ToLower(strings.TrimSpace(encoding)); e {
+	case "", "utf-8", "utf8":
+		d.Encoding = "utf-8"
+	case "windows-1252", "cp1252", "latin1", "iso-8859-1":
+		d.Encoding = "windows-1252"
+	default:
+		return d, fmt.Errorf("invalid --csv-encoding %q (use utf-8 or windows-1252)", encoding)
+	}
+
+	// BOM só faz sentido em UTF-8; em Windows-1252 os bytes EF BB BF virariam "ï»¿".
+	d.BOM = bom && d.Encoding == "utf-8"
+	return d, nil
+}
+
+func parseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "", ";", "semicolon":
+		return ';', nil
+	case ",", "comma":
+		return ',', nil
+	case "\\t", "\t", "tab":
+		return '\t', nil
+	case "|", "pipe":
+		return '|', nil
+	}
+	return 0, fmt.Errorf("invalid --csv-delimiter %q (use ; , tab or |)", s)
+}
+
+// toGoLayout aceita tanto um layout Go ("02/01/2006 15:04") quanto o estilo
+// Excel/Java ("dd/MM/yyyy HH:mm").
+func toGoLayout(f string) string {
+	if strings.Contains(f, "2006") {
+		return f
+	}
+	r := strings.NewReplacer(
+		"yyyy", "2006",
+		"yy", "06",
+		"MM", "01",
+		"dd", "02",
+		"HH", "15",
+		"mm", "04",
+		"ss", "05",
+	)
+	return r.Replace(f)
+}
+
+// layoutProbe tem ano, mês, dia e hora distintos entre si, para que um campo
+// trocado (mm = minutos no lugar do mês) apareça na volta.
+var layoutProbe = time.Date(2025, time.November, 23, 17, 45, 56, 0, time.UTC)
+
+// checkDateLayout confere que o layout escreve e relê a data: formata
+// layoutProbe e lê de volta. toGoLayout só troca texto, então "dd/mm/yyyy"
+// (mm = minutos) ou "DD/MM/YYYY" (sem tradução) só aparecem aqui.
+func checkDateLayout(layout string) error {
+	s := layoutProbe.Format(layout)
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return fmt.Errorf("cannot read back %q", s)
+	}
+	if t.Year() != layoutProbe.Year() || t.Month() != layoutProbe.Month() || t.Day() != layoutProbe.Day() {
+		return fmt.Errorf("layout must have year, month and day (e.g. dd/MM/yyyy HH:mm); got %q for 2025-11-23", s)
+	}
+	return nil
+}
+
+// formatRecord aplica data e separador decimal do dialeto a um record no
+// layout interno. Não altera o slice recebido.
+func (d csvDialect) formatRecord(record []string) []string {
+	out := make([]string, len(record))
+	copy(out, record)
+	if d.DateLayout != createdLayout && len(out) >= 24 {
+		// Só a hora de parede muda de formato; o fuso não importa aqui.
+		if t, ok := parseCreated(out[22], time.UTC); ok {
+			out[22] = t.Format(d.DateLayout)
+		}
+	}
+	if d.Decimal == "," {
+		for i, v := range out {
+			if strings.Contains(v, ".") {
+				if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					out[i] = strings.Replace(v, ".", ",", 1)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// parseDate lê "Data - Criação" de um CSV escrito com este dialeto, na hora
+// de parede do banco de onde o CSV saiu (loc).
+func (d csvDialect) parseDate(s string, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation(d.DateLayout, strings.TrimSpace(s), loc)
+	if err != nil {
+		return parseCreated(s, loc)
+	}
+	return t, true
+}
+
+// dialectWriter é um csv.Writer com política de aspas configurável
+// (encoding/csv só sabe fazer o "minimal").
+type dialectWriter struct {
+	d   csvDialect
+	w   *bufio.Writer
+	enc io.WriteCloser // conversão de encoding; nil em UTF-8
+	err error
+}
+
+// newWriter devolve um writer que já escreve o BOM (se for o caso) e converte
+// para o encoding do dialeto.
+func (d csvDialect) newWriter(w io.Writer) (*dialectWriter, error) {
+	dw := &dialectWriter{d: d}
+	if d.Encoding == "windows-1252" {
+		// Caracteres sem equivalente em Windows-1252 (ex.: emoji no texto livre) viram "\x1a"
+		// em vez de abortar o export.
+		dw.enc = transform.NewWriter(w, encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()))
+		w = dw.enc
+	}
+	dw.w = bufio.NewWriter(w)
+	if d.BOM {
+		// Excel costuma interpretar CSV como ANSI/Windows-1252 sem BOM.
+		// Escrevendo BOM UTF-8 (EF BB BF), ele detecta UTF-8 e mantém acentos (ã, ç, é...).
+		if _, err := dw.w.WriteString("\ufeff"); err != nil {
+			return nil, fmt.Errorf("write BOM: %w", err)
+		}
+	}
+	return dw, nil
+}
+
+func (dw *dialectWriter) Write(record []string) error {
+	if dw.err != nil {
+		return dw.err
+	}
+	for i, field := range record {
+		if i > 0 {
+			dw.w.WriteRune(dw.d.Comma)
+		}
+		quote := false
+		switch dw.d.Quote {
+		case "all":
+			quote = true
+		case "minimal":
+			quote = dw.fieldNeedsQuotes(field)
+		case "none":
+			// Sem aspas, só delimitador, aspas e quebra de linha quebram a leitura;
+			// espaço ou tab no início é lido de volta sem mudança.
+			if strings.ContainsRune(field, dw.d.Comma) || strings.ContainsAny(field, "\"\r\n") {
+				dw.err = fmt.Errorf("field %q needs quoting but --csv-quote=none", field)
+				return dw.err
+			}
+		}
+		if !quote {
+			dw.w.WriteString(field)
+			continue
+		}
+		dw.w.WriteByte('"')
+		dw.w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		dw.w.WriteByte('"')
+	}
+	if dw.d.CRLF {
+		_, dw.err = dw.w.WriteString("\r\n")
+	} else {
+		dw.err = dw.w.WriteByte('\n')
+	}
+	return dw.err
+}
+
+// fieldNeedsQuotes segue a mesma regra de encoding/csv.
+func (dw *dialectWriter) fieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, dw.d.Comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	return field[0] == ' ' || field[0] == '\t'
+}
+
+// Flush deve ser chamado uma única vez, no fim: também encerra a conversão de encoding.
+func (dw *dialectWriter) Flush() {
+	if err := dw.w.Flush(); err != nil && dw.err == nil {
+		dw.err = err
+	}
+	if dw.enc != nil {
+		if err := dw.enc.Close(); err != nil && dw.err == nil {
+			dw.err = err
+		}
+	}
+}
+
+func (dw *dialectWriter) Error() error {
+	return dw.err
+}
+
+// newReader abre um CSV escrito com este dialeto (decodifica o encoding;
+// o BOM, se houver, fica no primeiro campo e quem lê o cabeçalho remove).
+func (d csvDialect) newReader(r io.Reader) *csv.Reader {
+	if d.Encoding == "windows-1252" {
+		r = transform.NewReader(r, charmap.Windows1252.NewDecoder())
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = d.Comma
+	cr.FieldsPerRecord = -1
+	return cr
+}
+
+// createDialectCSV grava path no dialeto ativo: o cabeçalho e depois o que
+// fill escrever. Com erro, o arquivo pela metade é removido.
+func createDialectCSV(path string, header []string, fill func(w *dialectWriter) error) (err error) {
+	name := filepath.Base(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	defer removeOnError(path, &err)
+	defer f.Close()
+	w, err := dialect.newWriter(f)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if err := fill(w); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("flush %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+// writeDialectCSV grava as linhas (no layout interno: ponto decimal e datas
+// em createdLayout) já convertidas para o dialeto ativo.
+func writeDialectCSV(path string, header []string, rows [][]string) error {
+	return createDialectCSV(path, header, func(w *dialectWriter) error {
+		for _, rec := range rows {
+			if err := w.Write(dialect.formatRecord(rec)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeRecords(t *testing.T, d csvDialect, records ...[]string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := d.newWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			return buf.String(), err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func TestDialectWriter(t *testing.T) {
+	rec := []string{"Andar 3", "", " recuo", "a;b", `diz "oi"`}
+	tests := []struct {
+		name                                 string
+		delim, quote, eol, decimal, encoding string
+		bom                                  bool
+		want                                 string
+	}{
+		{"default", ";", "minimal", "lf", ".", "utf-8", true,
+			"\ufeffAndar 3;;\" recuo\";\"a;b\";\"diz \"\"oi\"\"\"\n"},
+		{"all, crlf", ";", "all", "crlf", ".", "utf-8", false,
+			"\"Andar 3\";\"\";\" recuo\";\"a;b\";\"diz \"\"oi\"\"\"\r\n"},
+		{"comma delimiter", ",", "minimal", "lf", ".", "utf-8", false,
+			"Andar 3,,\" recuo\",a;b,\"diz \"\"oi\"\"\"\n"},
+		{"tab delimiter", "tab", "minimal", "lf", ".", "utf-8", false,
+			"Andar 3\t\t\" recuo\"\ta;b\t\"diz \"\"oi\"\"\"\n"},
+		{"pipe delimiter", "|", "minimal", "lf", ".", "utf-8", false,
+			"Andar 3||\" recuo\"|a;b|\"diz \"\"oi\"\"\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := newCSVDialect(tt.delim, tt.quote, tt.eol, "", tt.decimal, tt.encoding, tt.bom)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := writeRecords(t, d, rec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			// O reader do mesmo dialeto devolve os campos originais.
+			back, err := d.newReader(strings.NewReader(strings.TrimPrefix(got, "\ufeff"))).Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(back, "\x00") != strings.Join(rec, "\x00") {
+				t.Errorf("read back %q, want %q", back, rec)
+			}
+		})
+	}
+}
+
+func TestDialectQuoteNone(t *testing.T) {
+	d, err := newCSVDialect(";", "none", "lf", "", ".", "utf-8", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Espaço ou tab no início, vírgula (outro delimitador) e `\.` passam sem aspas.
+	ok := []string{" recuo", "\tcom tab", "a,b", `\.`, ""}
+	got, err := writeRecords(t, d, ok)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := " recuo;\tcom tab;a,b;\\.;\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, field := range []string{"a;b", `diz "oi"`, "linha\nquebrada", "fim\r"} {
+		if _, err := writeRecords(t, d, []string{"x", field}); err == nil || !strings.Contains(err.Error(), "--csv-quote=none") {
+			t.Errorf("field %q: err = %v, want quoting error", field, err)
+		}
+	}
+}
+
+func TestDialectWindows1252(t *testing.T) {
+	d, err := newCSVDialect(";", "minimal", "lf", "", ".", "cp1252", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.BOM {
+		t.Error("BOM must be dropped for windows-1252")
+	}
+	got, err := writeRecords(t, d, []string{"Recepção", "ótimo 😀", "€"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ç = E7, ã = E3, ó = F3, € = 80; o emoji não existe e vira SUB (1A).
+	want := "Recep\xe7\xe3o;\xf3timo \x1a;\x80\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	back, err := d.newReader(strings.NewReader(got)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back[0] != "Recepção" || back[1] != "ótimo \x1a" || back[2] != "€" {
+		t.Errorf("read back %q", back)
+	}
+}
+
+func TestToGoLayout(t *testing.T) {
+	tests := map[string]string{
+		"dd/MM/yyyy":          "02/01/2006",
+		"dd/MM/yyyy HH:mm":    "02/01/2006 15:04",
+		"yyyy-MM-dd HH:mm:ss": "2006-01-02 15:04:05",
+		"dd.MM.yy":            "02.01.06",
+		"02/01/2006 15:04":    "02/01/2006 15:04", // já é layout Go
+	}
+	for in, want := range tests {
+		if got := toGoLayout(in); got != want {
+			t.Errorf("toGoLayout(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCSVDateFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		ok     bool
+	}{
+		{"dd/MM/yyyy HH:mm", true},
+		{"dd.MM.yy", true},
+		{"02/01/2006 15:04", true},
+		{"Jan 2, 2006", true},
+		{"dd/mm/yyyy", false}, // mm = minutos: sem mês
+		{"DD/MM/YYYY", false}, // não é traduzido
+		{"MM/yyyy", false},    // sem dia
+		{"dd/MM HH:mm", false},
+		{"15:04", false},
+		{"relatorio", false},
+	}
+	for _, tt := range tests {
+		_, err := newCSVDialect("", "", "", tt.format, "", "", true)
+		if (err == nil) != tt.ok {
+			t.Errorf("--csv-date-format %q: err = %v, want ok %v", tt.format, err, tt.ok)
+		}
+		if err != nil && !strings.Contains(err.Error(), "--csv-date-format") {
+			t.Errorf("--csv-date-format %q: error should name the flag: %v", tt.format, err)
+		}
+	}
+}
+
+func TestDialectFormatRecord(t *testing.T) {
+	d, err := newCSVDialect(";", "minimal", "lf", "dd/MM/yyyy HH:mm", ",", "utf-8", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := make([]string, 24)
+	rec[0] = "12.5"
+	rec[1] = "v1.2"
+	rec[22] = "2025-12-03 14:05:00"
+	out := d.formatRecord(rec)
+	if out[0] != "12,5" || out[1] != "v1.2" || out[22] != "03/12/2025 14:05" {
+		t.Errorf("formatRecord = %q, %q, %q", out[0], out[1], out[22])
+	}
+	if rec[0] != "12.5" || rec[22] != "2025-12-03 14:05:00" {
+		t.Error("formatRecord changed its input")
+	}
+	if ts, ok := d.parseDate(out[22], time.UTC); !ok || ts.Format(createdLayout) != "2025-12-03 14:05:00" {
+		t.Errorf("parseDate(%q) = %v, %v", out[22], ts, ok)
+	}
+}
+
+func TestNewCSVDialectErrors(t *testing.T) {
+	for _, tt := range []struct{ delim, quote, eol, decimal, enc, want string }{
+		{":", "", "", "", "", "--csv-delimiter"},
+		{"", "some", "", "", "", "--csv-quote"},
+		{"", "", "cr", "", "", "--csv-eol"},
+		{",", "", "", ",", "", "cannot be used"},
+		{"", "", "", "", "utf-16", "--csv-encoding"},
+	} {
+		if _, err := newCSVDialect(tt.delim, tt.quote, tt.eol, "", tt.decimal, tt.enc, true); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: err = %v, want %q", tt, err, tt.want)
+		}
+	}
+}
+
+func TestWriteDialectCSV(t *testing.T) {
+	saved := dialect
+	defer func() { dialect = saved }()
+	d, err := newCSVDialect(";", "minimal", "lf", "", ",", "utf-8", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialect = d
+
+	path := filepath.Join(t.TempDir(), "k.csv")
+	if err := writeDialectCSV(path, []string{"questao", "pct"}, [][]string{{"1", "87.5"}, {"2", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "questao;pct\n1;87,5\n2;\n"; string(b) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+
+	// Erro no meio: o arquivo pela metade não fica no disco.
+	dialect.Quote = "none"
+	err = writeDialectCSV(path, []string{"questao"}, [][]string{{"1"}, {"a;b"}})
+	if err == nil || !strings.Contains(err.Error(), "write k.csv") {
+		t.Errorf("err = %v, want write error", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+}
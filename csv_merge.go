@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Leitura de um ou mais CSVs para o PPTX (--pptx-from) e para o --publish.
+//
+// Cada arquivo tem o cabeçalho mapeado por nome (mapCSVHeader), então os
+// arquivos não precisam ter as mesmas colunas. As linhas são convertidas para o
+// layout do record (ver scanRowToStrings), ordenadas por Data - Criação e, se
+// pedido, passam pelo mesmo dedupe do export: assim uma duplicata que ficou na
+// fronteira entre dois meses também sai.
+
+type csvMergeResult struct {
+	Counts  *answerCounts
+	Files   []string
+	Rows    int
+	Skipped int
+	// First/Last são a menor e a maior Data - Criação lidas (zero se nenhuma).
+	First, Last time.Time
+}
+
+// expandCSVPaths aceita uma lista separada por vírgula de arquivos, globs e
+// pastas (ex.: "relatorio_2025_1*.csv,relatorio_2025_09.csv,backfill/2025_08").
+// Uma pasta vale pelos *.csv dentro dela. O mesmo arquivo citado duas vezes
+// (ex.: pelo glob e pelo nome) entra uma vez só.
+func expandCSVPaths(arg string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(arg, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		matches := []string{p}
+		pattern := p
+		if st, err := os.Stat(p); err == nil && st.IsDir() {
+			pattern = filepath.Join(p, "*.csv")
+		}
+		if pattern != p || strings.ContainsAny(p, "*?[") {
+			m, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("glob %q: %w", p, err)
+			}
+			if len(m) == 0 {
+				return nil, fmt.Errorf("no files match %q", pattern)
+			}
+			sort.Strings(m)
+			matches = m
+		}
+		for _, m := range matches {
+			if key := filepath.Clean(m); !seen[key] {
+				seen[key] = true
+				out = append(out, m)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no CSV files in %q", arg)
+	}
+	return out, nil
+}
+
+type mergedRow struct {
+	rec  []string
+	unit string // coluna Unidade do CSV consolidado (--units)
+	t    time.Time
+	hasT bool
+}
+
+// readCSVs lê e junta os arquivos. loc é o fuso do banco de onde os CSVs
+// saíram (Data - Criação é hora de parede); dd nil desliga o dedupe.
+func readCSVs(paths []string, dd *deduper, loc *time.Location) (*csvMergeResult, error) {
+	var rows []mergedRow
+	present := map[int]bool{}
+	for _, p := range paths {
+		fileRows, layout, err := readCSVRecords(p, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		for _, qc := range layout.Questions {
+			present[qc.Number] = true
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	// Ordena por data só se todas as linhas têm data; senão mantém a ordem dos arquivos.
+	allTimed := true
+	for _, r := range rows {
+		if !r.hasT {
+			allTimed = false
+			break
+		}
+	}
+	if allTimed && len(paths) > 1 {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].t.Before(rows[j].t) })
+	}
+
+	// Layout do record (exporter) com as perguntas presentes em pelo menos um arquivo.
+	layout := csvLayout{Floor: 0, Patient: 1, Created: 22, Registrar: 23, Unit: -1}
+	for _, qc := range questionColumns() {
+		if present[qc.Number] {
+			layout.Questions = append(layout.Questions, qc)
+		}
+	}
+
+	res := &csvMergeResult{Counts: newAnswerCounts(layout), Files: paths}
+	for _, r := range rows {
+		patient := r.rec[1]
+		if r.unit != "" && patient != "" {
+			patient = r.unit + "\x00" + patient // mesmo nome em outra unidade é outro paciente
+		}
+		if dd != nil && dd.isDup(patient, r.rec[22]) {
+			res.Skipped++
+			continue
+		}
+		res.Counts.add(r.rec, r.unit)
+		res.Rows++
+		if r.hasT {
+			if res.First.IsZero() || r.t.Before(res.First) {
+				res.First = r.t
+			}
+			if r.t.After(res.Last) {
+				res.Last = r.t
+			}
+		}
+	}
+	return res, nil
+}
+
+// periodAnswers são as respostas de um período, lidas uma vez (com o dedupe
+// do deck) para todos os relatórios: KPIs, mapa de calor, fatores, stats,
+// metas, deck, métricas e aviso. A leitura acontece no primeiro pedido, então
+// sem nenhum relatório (ou sem csv no --format) o arquivo nem é aberto.
+type periodAnswers struct {
+	Paths  []string
+	loc    *time.Location
+	dd     *deduper
+	merged *csvMergeResult
+	err    error
+	read   bool
+}
+
+// newPeriodAnswers: loc é o fuso do banco de onde o CSV saiu (no --units, o
+// da unidade).
+func newPeriodAnswers(paths []string, opts pptxOptions, loc *time.Location) *periodAnswers {
+	a := &periodAnswers{Paths: paths, loc: loc}
+	if opts.Dedupe {
+		a.dd = newDeduper(opts.DedupeSec, loc)
+	}
+	return a
+}
+
+// load devolve o resultado (ou o erro) da primeira leitura.
+func (a *periodAnswers) load() (*csvMergeResult, error) {
+	if !a.read {
+		a.merged, a.err = readCSVs(a.Paths, a.dd, a.loc)
+		a.read = true
+	}
+	return a.merged, a.err
+}
+
+// readCSVRecords lê um arquivo e devolve as linhas no layout do record.
+func readCSVRecords(path string, loc *time.Location) ([]mergedRow, csvLayout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, csvLayout{}, fmt.Errorf("open csv: %w", err)
+	}
+	defer f.Close()
+
+	r := dialect.newReader(f)
+
+	headerRow, err := r.Read()
+	if err != nil {
+		return nil, csvLayout{}, fmt.Errorf("read header: %w", err)
+	}
+	if len(headerRow) > 0 {
+		headerRow[0] = strings.TrimPrefix(headerRow[0], "\ufeff") // handle UTF-8 BOM
+	}
+
+	// As colunas são localizadas pelo nome (ver mapCSVHeader); qualquer
+	// subconjunto de perguntas é aceito.
+	layout, err := mapCSVHeader(headerRow)
+	if err != nil {
+		return nil, csvLayout{}, err
+	}
+	if missing := layout.missingQuestions(); len(missing) > 0 {
+		slog.Warn("CSV sem algumas colunas (sem slide para elas)", "path", path, "missing", strings.Join(missing, ", "))
+	}
+
+	var out []mergedRow
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvLayout{}, fmt.Errorf("read csv: %w", err)
+		}
+		rec := make([]string, 24)
+		rec[0] = layout.field(row, layout.Floor)
+		rec[1] = layout.field(row, layout.Patient)
+		rec[23] = layout.field(row, layout.Registrar)
+		for _, qc := range layout.Questions {
+			rec[1+qc.Number] = layout.field(row, qc.Index)
+		}
+		mr := mergedRow{rec: rec, unit: layout.field(row, layout.Unit)}
+		if created := layout.field(row, layout.Created); created != "" {
+			if t, ok := dialect.parseDate(created, loc); ok {
+				mr.t, mr.hasT = t, true
+				rec[22] = t.Format(createdLayout)
+			} else {
+				rec[22] = created
+			}
+		}
+		out = append(out, mr)
+	}
+	return out, layout, nil
+}
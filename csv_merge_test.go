@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriodAnswersReadOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.csv")
+	csv := "Data;Andar;Paciente;questao1\n" +
+		"2025-12-03 14:05:00;3;Maria;4\n" +
+		"2025-12-03 14:05:00;3;Maria;4\n" + // duplicata consecutiva
+		"2025-12-04 09:00:00;4;João;2\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in := newPeriodAnswers([]string{path}, pptxOptions{Dedupe: true}, time.UTC)
+	first, err := in.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Rows != 2 || first.Skipped != 1 {
+		t.Errorf("rows = %d, skipped = %d, want 2 and 1", first.Rows, first.Skipped)
+	}
+
+	// Os relatórios seguintes usam a mesma leitura, sem abrir o arquivo de novo.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	again, err := in.load()
+	if err != nil || again != first {
+		t.Errorf("second load = %p, %v; want the first result %p", again, err, first)
+	}
+
+	if _, err := newPeriodAnswers([]string{path}, pptxOptions{}, time.UTC).load(); err == nil {
+		t.Error("expected error for a missing CSV")
+	}
+}
+
+func TestExpandCSVPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"relatorio_2025_10.csv", "relatorio_2025_11.csv", "relatorio_2025_12.csv", "notas.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	empty := filepath.Join(dir, "vazia")
+	if err := os.Mkdir(empty, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name, arg string
+		want      []string
+		wantErr   string
+	}{
+		{"glob", p("relatorio_2025_1[12].csv"), []string{p("relatorio_2025_11.csv"), p("relatorio_2025_12.csv")}, ""},
+		{"folder", dir, []string{p("relatorio_2025_10.csv"), p("relatorio_2025_11.csv"), p("relatorio_2025_12.csv")}, ""},
+		{"same file twice", p("relatorio_2025_10.csv") + ", " + dir + "/./relatorio_2025_10.csv", []string{p("relatorio_2025_10.csv")}, ""},
+		{"glob and name", p("relatorio_2025_12.csv") + "," + p("*_12.csv"), []string{p("relatorio_2025_12.csv")}, ""},
+		{"glob without matches", p("relatorio_2024_*.csv"), nil, "no files match"},
+		{"folder without csv", empty, nil, "no files match"},
+		{"empty list", " , ", nil, "no CSV files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandCSVPaths(tt.arg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expandCSVPaths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// As duplicatas na virada do mês (a mesma resposta exportada nos dois
+// arquivos) saem; o resto é contado no mês da Data - Criação.
+func TestReadCSVsMergesMonths(t *testing.T) {
+	dir := t.TempDir()
+	const header = "Data;Andar;Paciente;questao1\n"
+	nov := header +
+		"2025-11-10 10:00:00;3;Maria;Excelente\n" +
+		"2025-11-30 23:59:00;4;João;Ruim\n"
+	dec := header +
+		"2025-11-30 23:59:00;4;João;Ruim\n" + // repetida do arquivo de novembro
+		"2025-12-01 08:00:00;3;Ana;Excelente\n" +
+		"2025-12-02 09:00:00;3;Rui;Boa\n"
+	// Os arquivos fora de ordem: a junção ordena pela data.
+	paths := []string{filepath.Join(dir, "dez.csv"), filepath.Join(dir, "nov.csv")}
+	for i, content := range []string{dec, nov} {
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := readCSVs(paths, newDeduper(0, time.UTC), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 4 || res.Skipped != 1 {
+		t.Errorf("rows = %d, skipped = %d; want 4 and 1", res.Rows, res.Skipped)
+	}
+	if first, last := res.First.Format(createdLayout), res.Last.Format(createdLayout); first != "2025-11-10 10:00:00" || last != "2025-12-02 09:00:00" {
+		t.Errorf("period = %s .. %s", first, last)
+	}
+
+	ac := res.Counts
+	if got := ac.months(); strings.Join(got, ",") != "2025-11,2025-12" {
+		t.Fatalf("months = %v", got)
+	}
+	want := map[string]map[string]int{
+		"2025-11": {"Excelente": 1, "Ruim": 1},
+		"2025-12": {"Excelente": 1, "Boa": 1},
+	}
+	for month, counts := range want {
+		got := ac.ByMonth[month][0]
+		if len(got) != len(counts) {
+			t.Errorf("%s: %v, want %v", month, got, counts)
+		}
+		for k, n := range counts {
+			if got[k] != n {
+				t.Errorf("%s: %s = %d, want %d", month, k, got[k], n)
+			}
+		}
+	}
+	if ac.Total[0]["Excelente"] != 2 || ac.Total[0]["Ruim"] != 1 {
+		t.Errorf("total = %v", ac.Total[0])
+	}
+
+	table := monthBreakdownTable(ac, 0, ac.months())
+	if len(table.Header) != 3 || table.Header[1] != msgs.shortMonth("2025-11") {
+		t.Errorf("header = %v", table.Header)
+	}
+	last := table.Rows[len(table.Rows)-1]
+	if strings.Join(last, ",") != "n,2,2" {
+		t.Errorf("n row = %v, want n,2,2", last)
+	}
+
+	// Sem dedupe a linha repetida conta duas vezes.
+	res, err = readCSVs(paths, nil, time.UTC)
+	if err != nil || res.Rows != 5 || res.Skipped != 0 {
+		t.Errorf("without dedupe: rows = %d, skipped = %d, err = %v", res.Rows, res.Skipped, err)
+	}
+}
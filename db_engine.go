@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// O schema adms_* é o mesmo nos três bancos; o que muda é o driver, o
+// placeholder ('?' vs '$1') e como datas entram e saem.
+const (
+	engineMySQL    = "mysql"
+	enginePostgres = "postgres"
+	engineSQLite   = "sqlite"
+)
+
+// detectEngine usa --driver/DB_DRIVER quando informado; senão olha o DSN.
+func detectEngine(hint, dsn string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(hint)) {
+	case "mysql":
+		return engineMySQL, nil
+	case "postgres", "postgresql", "pgx", "pg":
+		return enginePostgres, nil
+	case "sqlite", "sqlite3":
+		return engineSQLite, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid --driver %q (use mysql, postgres or sqlite)", hint)
+	}
+
+	d := strings.ToLower(strings.TrimSpace(dsn))
+	switch {
+	case strings.HasPrefix(d, "postgres://"), strings.HasPrefix(d, "postgresql://"):
+		return enginePostgres, nil
+	case strings.HasPrefix(d, "sqlite:"), strings.HasPrefix(d, "file:"),
+		strings.HasSuffix(d, ".db"), strings.HasSuffix(d, ".sqlite"), strings.HasSuffix(d, ".sqlite3"):
+		return engineSQLite, nil
+	}
+	return engineMySQL, nil
+}
+
+func openSource(engine, dsn string) (*sql.DB, error) {
+	switch engine {
+	case enginePostgres:
+		return sql.Open("pgx", dsn)
+	case engineSQLite:
+		return sql.Open("sqlite", strings.TrimPrefix(dsn, "sqlite:"))
+	}
+	return sql.Open("mysql", ensureParseTime(dsn))
+}
+
+// rebind troca os '?' da query pelos placeholders do banco ($1, $2... no Postgres).
+// '?' dentro de 'texto' ou "identificador" fica como está; o apóstrofo
+// dobrado de escape fecha e reabre a string, então também funciona.
+func rebind(engine, q string) string {
+	if engine != enginePostgres {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range q {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// O fuso em que eq.created (DATETIME, sem fuso) foi gravado (--db-tz) é o da
+// fonte: main resolve um para o banco e um para os CSVs do --pptx-from e do
+// --compare; no --units cada unidade tem o seu. Ele vai explícito até quem lê
+// datas (exportOptions.DBLoc, readCSVs, newDeduper), sem estado global.
+
+// resolveDBTZ: --db-tz; senão o loc= do DSN do MySQL; senão o fuso da máquina.
+func resolveDBTZ(flagTZ, engine, dsn string) (*time.Location, error) {
+	name := strings.TrimSpace(flagTZ)
+	if name == "" && engine == engineMySQL {
+		name = dsnParam(dsn, "loc")
+	}
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --db-tz %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// dsnParam lê um parâmetro da parte "?a=b&c=d" de um DSN do MySQL.
+func dsnParam(dsn, key string) string {
+	i := strings.Index(dsn, "?")
+	if i < 0 {
+		return ""
+	}
+	vals, err := url.ParseQuery(dsn[i+1:])
+	if err != nil {
+		return ""
+	}
+	return vals.Get(key)
+}
+
+// timeArg prepara um limite de período para a query: a hora "de parede" no
+// fuso do banco, como texto. Assim nenhum driver converte para UTC (o
+// go-sql-driver/mysql faz isso com time.Time quando o DSN não tem loc=), e no
+// SQLite, onde DATETIME é texto, a comparação usa o mesmo formato gravado.
+// No Postgres vai também o deslocamento: timestamptz compara o instante certo
+// (e não no TimeZone da sessão) e timestamp sem fuso simplesmente o ignora.
+func timeArg(engine string, t time.Time, loc *time.Location) string {
+	if engine == enginePostgres {
+		return t.In(loc).Format(createdLayout + "-07:00")
+	}
+	return t.In(loc).Format(createdLayout)
+}
+
+// inDBLoc reinterpreta a hora de parede lida do banco no fuso do banco (loc).
+// Os drivers devolvem DATETIME marcado como UTC (ou com o loc= do DSN).
+func inDBLoc(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// zonedTimeType diz se a coluna guarda um instante (timestamptz do
+// Postgres), que o driver já devolve certo. DATETIME do MySQL, timestamp sem
+// fuso e o texto do SQLite são hora de parede no fuso do banco.
+func zonedTimeType(dbType string) bool {
+	return strings.EqualFold(dbType, "TIMESTAMPTZ")
+}
+
+// createdIn devolve created no fuso do banco: instantes só mudam de fuso; hora
+// de parede é reinterpretada por inDBLoc.
+func createdIn(created nullTimeAny, zonedCol bool, loc *time.Location) time.Time {
+	if zonedCol || created.Zoned {
+		return created.Time.In(loc)
+	}
+	return inDBLoc(created.Time, loc)
+}
+
+// warnTZ avisa quando o fuso da máquina (ou do --tz) e o do banco (dbLoc)
+// diferem no período: é aí que respostas mudam de mês se algo estiver mal
+// configurado.
+func warnTZ(reportLoc, dbLoc *time.Location, at time.Time) string {
+	_, dbOff := at.In(dbLoc).Zone()
+	_, localOff := at.In(time.Local).Zone()
+	_, reportOff := at.In(reportLoc).Zone()
+	if dbOff == localOff && dbOff == reportOff {
+		return ""
+	}
+	return fmt.Sprintf("fusos diferentes - máquina %s, período (--tz) %s, banco (--db-tz) %s; os limites foram convertidos para o fuso do banco",
+		zoneLabel(time.Local, at), zoneLabel(reportLoc, at), zoneLabel(dbLoc, at))
+}
+
+func zoneLabel(loc *time.Location, at time.Time) string {
+	_, off := at.In(loc).Zone()
+	return fmt.Sprintf("%s (%+03d:%02d)", loc.String(), off/3600, abs(off%3600)/60)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// nullTimeAny é um sql.NullTime que também aceita texto (SQLite, ou MySQL sem
+// parseTime=true). Texto sem deslocamento é hora de parede e sai marcado como
+// UTC, como os drivers fazem com DATETIME; createdIn põe no fuso do banco.
+type nullTimeAny struct {
+	Time  time.Time
+	Valid bool
+	// Zoned: o texto trazia deslocamento (ex.: RFC 3339), então Time já é o instante.
+	Zoned bool
+}
+
+func (n *nullTimeAny) Scan(v any) error {
+	switch x := v.(type) {
+	case nil:
+		n.Time, n.Valid, n.Zoned = time.Time{}, false, false
+		return nil
+	case time.Time:
+		n.Time, n.Valid, n.Zoned = x, true, false
+		return nil
+	case []byte:
+		return n.parse(string(x))
+	case string:
+		return n.parse(x)
+	}
+	return fmt.Errorf("created: unsupported type %T", v)
+}
+
+func (n *nullTimeAny) parse(s string) error {
+	s = strings.TrimSpace(s)
+	n.Zoned = false
+	if s == "" {
+		n.Time, n.Valid = time.Time{}, false
+		return nil
+	}
+	for _, layout := range []string{createdLayout, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			n.Time, n.Valid = t, true
+			return nil
+		}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999-07"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			n.Time, n.Valid, n.Zoned = t, true, true
+			return nil
+		}
+	}
+	return fmt.Errorf("created: cannot parse %q", s)
+}
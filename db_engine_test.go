@@ -0,0 +1,206 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatedIn(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	instant := time.Date(2025, 12, 1, 4, 0, 0, 0, time.UTC) // 01:00 em -03
+	tests := []struct {
+		name      string
+		scan      any
+		tzCol     bool // coluna timestamptz
+		want      string
+		wantZoned bool
+	}{
+		// DATETIME do MySQL e timestamp sem fuso: hora de parede no fuso do banco.
+		{"wall clock from driver", instant, false, "2025-12-01 04:00:00", false},
+		{"sqlite text", "2025-12-01 01:00:00", false, "2025-12-01 01:00:00", false},
+		// timestamptz e texto com deslocamento já são o instante: só mudam de fuso.
+		{"timestamptz", instant, true, "2025-12-01 01:00:00", false},
+		{"text with offset", "2025-12-01T04:00:00Z", false, "2025-12-01 01:00:00", true},
+		{"text with short offset", "2025-12-01 06:00:00+02", false, "2025-12-01 01:00:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n nullTimeAny
+			if err := n.Scan(tt.scan); err != nil {
+				t.Fatal(err)
+			}
+			if n.Zoned != tt.wantZoned {
+				t.Errorf("Zoned = %v, want %v", n.Zoned, tt.wantZoned)
+			}
+			got := createdIn(n, tt.tzCol, sp)
+			if s := got.Format(createdLayout); s != tt.want || got.Location() != sp {
+				t.Errorf("createdIn = %s (%v), want %s in %v", s, got.Location(), tt.want, sp)
+			}
+		})
+	}
+}
+
+func TestNullTimeAny(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	ny := mustLoc(t, "America/New_York")
+	tests := []struct {
+		name    string
+		scan    any
+		loc     *time.Location // --db-tz
+		tzCol   bool
+		want    string // hora de parede no fuso do banco
+		instant string // o mesmo momento em UTC
+		zoned   bool
+	}{
+		// Texto sem deslocamento é hora de parede no --db-tz, não em UTC: 23:30
+		// de 30/11 em São Paulo continua em novembro (02:30 UTC de dezembro).
+		{"naive text in sp", "2025-11-30 23:30:00", sp, false, "2025-11-30 23:30:00", "2025-12-01T02:30:00Z", false},
+		{"naive bytes in sp", []byte("2025-11-30T23:30:00"), sp, false, "2025-11-30 23:30:00", "2025-12-01T02:30:00Z", false},
+		{"naive text in ny summer", "2025-07-01 12:00:00", ny, false, "2025-07-01 12:00:00", "2025-07-01T16:00:00Z", false},
+		{"naive text in ny winter", "2025-12-01 12:00:00", ny, false, "2025-12-01 12:00:00", "2025-12-01T17:00:00Z", false},
+		// Texto com deslocamento é o instante: o --db-tz só muda a exibição.
+		{"text with offset", "2025-12-01 01:00:00-03:00", time.UTC, false, "2025-12-01 04:00:00", "2025-12-01T04:00:00Z", true},
+		{"rfc3339 with offset", "2025-12-01T01:00:00-03:00", ny, false, "2025-11-30 23:00:00", "2025-12-01T04:00:00Z", true},
+		// time.Time de DATETIME (MySQL com loc=, pgx timestamp) é hora de parede
+		// mesmo vindo com um fuso: o fuso é o que o driver foi configurado a usar.
+		{"time with offset", time.Date(2025, 12, 1, 1, 0, 0, 0, sp), time.UTC, false, "2025-12-01 01:00:00", "2025-12-01T01:00:00Z", false},
+		// Em coluna timestamptz o mesmo valor é instante.
+		{"time with offset timestamptz", time.Date(2025, 12, 1, 1, 0, 0, 0, sp), time.UTC, true, "2025-12-01 04:00:00", "2025-12-01T04:00:00Z", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n nullTimeAny
+			if err := n.Scan(tt.scan); err != nil {
+				t.Fatal(err)
+			}
+			if !n.Valid || n.Zoned != tt.zoned {
+				t.Errorf("Valid = %v, Zoned = %v; want true, %v", n.Valid, n.Zoned, tt.zoned)
+			}
+			got := createdIn(n, tt.tzCol, tt.loc)
+			if s := got.Format(createdLayout); s != tt.want {
+				t.Errorf("wall clock = %s, want %s", s, tt.want)
+			}
+			if s := got.UTC().Format(time.RFC3339); s != tt.instant {
+				t.Errorf("instant = %s, want %s", s, tt.instant)
+			}
+		})
+	}
+
+	for _, v := range []any{nil, "", "  "} {
+		n := nullTimeAny{Time: time.Now(), Valid: true, Zoned: true}
+		if err := n.Scan(v); err != nil || n.Valid || n.Zoned {
+			t.Errorf("Scan(%q) = %+v, %v; want NULL", v, n, err)
+		}
+	}
+	var n nullTimeAny
+	if err := n.Scan("30/11/2025"); err == nil {
+		t.Error("unknown text layout must be an error")
+	}
+	if err := n.Scan(int64(1)); err == nil {
+		t.Error("unsupported type must be an error")
+	}
+}
+
+func TestTimeArg(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	at := time.Date(2025, 12, 1, 3, 0, 0, 0, time.UTC)
+	if got := timeArg(engineMySQL, at, sp); got != "2025-12-01 00:00:00" {
+		t.Errorf("mysql: %q", got)
+	}
+	if got := timeArg(enginePostgres, at, sp); got != "2025-12-01 00:00:00-03:00" {
+		t.Errorf("postgres: %q", got)
+	}
+}
+
+func TestDetectEngine(t *testing.T) {
+	tests := []struct {
+		hint, dsn, want string
+		wantErr         bool
+	}{
+		{"", "postgres://u:p@db:5432/adms", enginePostgres, false},
+		{"", "PostgreSQL://u@db/adms?sslmode=require", enginePostgres, false},
+		{"", "sqlite:/dados/adms.db", engineSQLite, false},
+		{"", "file:adms?mode=ro", engineSQLite, false},
+		{"", "C:/dados/adms.sqlite3", engineSQLite, false},
+		{"", "./adms.db", engineSQLite, false},
+		{"", "u:p@tcp(127.0.0.1:3306)/adms", engineMySQL, false},
+		{"", "", engineMySQL, false},
+		// --driver vence o DSN.
+		{"pgx", "u:p@tcp(db)/adms", enginePostgres, false},
+		{" SQLite3 ", "postgres://db/adms", engineSQLite, false},
+		{"MySQL", "adms.db", engineMySQL, false},
+		{"oracle", "postgres://db/adms", "", true},
+	}
+	for _, tt := range tests {
+		got, err := detectEngine(tt.hint, tt.dsn)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("detectEngine(%q, %q) = %q, %v; want %q", tt.hint, tt.dsn, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveDSN(t *testing.T) {
+	for _, k := range []string{"DB_DRIVER", "DB_DSN", "MYSQL_DSN", "MYSQL_HOST", "MYSQL_PORT", "MYSQL_DB", "MYSQL_USER", "MYSQL_PASS", "MYSQL_PASS_FILE",
+		"PG_HOST", "PG_PORT", "PG_DB", "PG_USER", "PG_PASS", "PG_SSLMODE", "SQLITE_PATH"} {
+		t.Setenv(k, "")
+	}
+	tests := []struct {
+		name           string
+		env            map[string]string
+		dsn, driver    string
+		engine, wantDS string
+		wantErr        string
+	}{
+		{"flag url", nil, "postgres://u:p@db/adms", "", enginePostgres, "postgres://u:p@db/adms", ""},
+		{"flag wins over env", map[string]string{"DB_DSN": "env.db"}, "u:p@tcp(db)/adms", "", engineMySQL, "u:p@tcp(db)/adms", ""},
+		{"DB_DSN", map[string]string{"DB_DSN": "sqlite:env.db"}, "", "", engineSQLite, "sqlite:env.db", ""},
+		{"MYSQL_DSN", map[string]string{"MYSQL_DSN": "u:p@tcp(db)/adms"}, "", "", engineMySQL, "u:p@tcp(db)/adms", ""},
+		{"mysql parts", map[string]string{"MYSQL_DB": "adms", "MYSQL_USER": "rel", "MYSQL_PASS": "p@ss"}, "", "", engineMySQL,
+			"rel:p@ss@tcp(127.0.0.1:3306)/adms?charset=utf8mb4&parseTime=true", ""},
+		{"postgres parts", map[string]string{"DB_DRIVER": "postgres", "PG_DB": "adms", "PG_USER": "rel", "PG_PASS": "p@ss", "PG_HOST": "db"}, "", "", enginePostgres,
+			"postgres://rel:p%40ss@db:5432/adms?sslmode=prefer", ""},
+		{"sqlite path", map[string]string{"SQLITE_PATH": "/dados/adms.db"}, "", "sqlite", engineSQLite, "/dados/adms.db", ""},
+		{"mysql missing", nil, "", "", "", "", "missing DSN"},
+		{"postgres missing", nil, "", "postgres", "", "", "PG_DB"},
+		{"sqlite missing", nil, "", "sqlite", "", "", "SQLITE_PATH"},
+		{"bad driver", nil, "u:p@tcp(db)/adms", "oracle", "", "", "invalid --driver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			engine, dsn, err := resolveDSN(tt.dsn, "", tt.driver)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || engine != tt.engine || dsn != tt.wantDS {
+				t.Errorf("resolveDSN = %q, %q, %v; want %q, %q", engine, dsn, err, tt.engine, tt.wantDS)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct{ engine, in, want string }{
+		{enginePostgres, "SELECT * FROM t WHERE a >= ? AND a < ?", "SELECT * FROM t WHERE a >= $1 AND a < $2"},
+		{enginePostgres, "SELECT 'quem? onde?' FROM t WHERE a = ?", "SELECT 'quem? onde?' FROM t WHERE a = $1"},
+		{enginePostgres, `SELECT "col?" FROM t WHERE a = ? AND b = 'it''s?' AND c = ?`, `SELECT "col?" FROM t WHERE a = $1 AND b = 'it''s?' AND c = $2`},
+		{enginePostgres, "SELECT 1", "SELECT 1"},
+		{engineMySQL, "SELECT '?' FROM t WHERE a = ?", "SELECT '?' FROM t WHERE a = ?"},
+		{engineSQLite, "WHERE a = ? AND b = ?", "WHERE a = ? AND b = ?"},
+	}
+	for _, tt := range tests {
+		if got := rebind(tt.engine, tt.in); got != tt.want {
+			t.Errorf("rebind(%s, %q) = %q, want %q", tt.engine, tt.in, got, tt.want)
+		}
+	}
+	// A query do export tem dois limites de período.
+	if got := rebind(enginePostgres, query); strings.Contains(got, "?") || !strings.Contains(got, "$2") || strings.Contains(got, "$3") {
+		t.Errorf("export query rebind:\n%s", got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// TLS do MySQL hospedado (CA própria, certificado de cliente) e senha/DSN
+// lidos de arquivo, para tirar segredo do .env:
+//   - --dsn-file: o DSN inteiro num arquivo (ex.: secret do Docker/Kubernetes);
+//   - MYSQL_PASS_FILE: só a senha, no lugar de MYSQL_PASS;
+//   - --tls-ca/--tls-cert/--tls-key/--tls-server-name/--tls-skip-verify (ou
+//     MYSQL_TLS_*): montam um tls.Config registrado no driver com
+//     mysql.RegisterTLSConfig e referenciado no DSN por tls=<nome>.
+
+// tlsConfigName é o nome registrado para as flags --tls-*; também serve no
+// --publish=mysql:...&tls=auto_relatorio.
+const tlsConfigName = "auto_relatorio"
+
+type mysqlTLS struct {
+	CA         string `yaml:"ca"`   // bundle PEM com a(s) CA(s) do servidor
+	Cert       string `yaml:"cert"` // certificado do cliente (PEM), com Key
+	Key        string `yaml:"key"`
+	ServerName string `yaml:"server_name"` // quando o host do DSN não é o nome do certificado
+	SkipVerify bool   `yaml:"skip_verify"` // só homologação
+}
+
+func (t mysqlTLS) enabled() bool {
+	return t.CA != "" || t.Cert != "" || t.Key != "" || t.ServerName != "" || t.SkipVerify
+}
+
+// mysqlTLSFromEnv completa as flags vazias com MYSQL_TLS_CA, MYSQL_TLS_CERT,
+// MYSQL_TLS_KEY, MYSQL_TLS_SERVER_NAME e MYSQL_TLS_SKIP_VERIFY.
+func mysqlTLSFromEnv(t mysqlTLS) mysqlTLS {
+	t.CA = firstNonEmpty(strings.TrimSpace(t.CA), os.Getenv("MYSQL_TLS_CA"))
+	t.Cert = firstNonEmpty(strings.TrimSpace(t.Cert), os.Getenv("MYSQL_TLS_CERT"))
+	t.Key = firstNonEmpty(strings.TrimSpace(t.Key), os.Getenv("MYSQL_TLS_KEY"))
+	t.ServerName = firstNonEmpty(strings.TrimSpace(t.ServerName), os.Getenv("MYSQL_TLS_SERVER_NAME"))
+	if !t.SkipVerify {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("MYSQL_TLS_SKIP_VERIFY"))) {
+		case "1", "true", "yes":
+			t.SkipVerify = true
+		}
+	}
+	return t
+}
+
+// config monta o tls.Config (TLS 1.2 ou mais novo).
+func (t mysqlTLS) config() (*tls.Config, error) {
+	if t.SkipVerify && t.CA != "" {
+		// Com skip-verify a CA não seria consultada: melhor recusar do que
+		// deixar quem configurou achar que o servidor está sendo verificado.
+		return nil, errors.New("tls: skip-verify ignores the CA; use one or the other")
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: t.ServerName}
+	if t.CA != "" {
+		pem, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, fmt.Errorf("tls ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca: no PEM certificates in %s", t.CA)
+		}
+		cfg.RootCAs = pool
+	}
+	if (t.Cert == "") != (t.Key == "") {
+		return nil, errors.New("tls: client certificate needs both cert and key")
+	}
+	if t.Cert != "" {
+		pair, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	if t.SkipVerify {
+		slog.Warn("TLS sem verificar o certificado do servidor (skip-verify): use só em homologação")
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
+// applyMySQLTLS registra t com o nome name e aponta o DSN para ele. Sem
+// opções de TLS devolve o DSN como veio.
+func applyMySQLTLS(dsn string, t mysqlTLS, name string) (string, error) {
+	if !t.enabled() {
+		return dsn, nil
+	}
+	if dsnParam(dsn, "tls") != "" {
+		return "", errors.New("the DSN already sets tls=; remove it or the TLS options")
+	}
+	cfg, err := t.config()
+	if err != nil {
+		return "", err
+	}
+	if err := mysql.RegisterTLSConfig(name, cfg); err != nil {
+		return "", fmt.Errorf("register tls config: %w", err)
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "tls=" + url.QueryEscape(name), nil
+}
+
+// readSecretFile lê uma senha ou DSN de arquivo, sem a quebra de linha final.
+// Avisa se outros usuários da máquina podem ler o arquivo.
+func readSecretFile(path string) (string, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && st.Mode().Perm()&0o077 != 0 {
+		slog.Warn("arquivo com segredo legível por outros usuários (use chmod 600)", "path", path, "mode", st.Mode().Perm().String())
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimRight(string(b), "\r\n")
+	if s == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return s, nil
+}
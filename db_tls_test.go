@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCA grava um certificado autoassinado (PEM) em dir/ca.pem.
+func writeCA(t *testing.T, dir string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "auto_relatorio test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMySQLTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := writeCA(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("isto não é um certificado\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    mysqlTLS
+		wantErr string
+	}{
+		{"ca", mysqlTLS{CA: ca, ServerName: "db.interno"}, ""},
+		{"skip verify", mysqlTLS{SkipVerify: true}, ""},
+		{"missing ca", mysqlTLS{CA: filepath.Join(dir, "nao_existe.pem")}, "tls ca:"},
+		{"invalid pem", mysqlTLS{CA: notPEM}, "no PEM certificates"},
+		{"skip verify with ca", mysqlTLS{CA: ca, SkipVerify: true}, "skip-verify ignores the CA"},
+		{"cert without key", mysqlTLS{Cert: ca}, "needs both cert and key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.opts.config()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.InsecureSkipVerify != tt.opts.SkipVerify || cfg.ServerName != tt.opts.ServerName {
+				t.Errorf("config = %+v", cfg)
+			}
+			if (cfg.RootCAs != nil) != (tt.opts.CA != "") {
+				t.Errorf("RootCAs = %v with CA %q", cfg.RootCAs, tt.opts.CA)
+			}
+		})
+	}
+}
+
+func TestApplyMySQLTLS(t *testing.T) {
+	ca := writeCA(t, t.TempDir())
+	const name = "auto_relatorio_test"
+
+	got, err := applyMySQLTLS("rel:x@tcp(db:3306)/adms?loc=Local", mysqlTLS{CA: ca}, name)
+	if err != nil || got != "rel:x@tcp(db:3306)/adms?loc=Local&tls="+name {
+		t.Errorf("applyMySQLTLS = %q, %v", got, err)
+	}
+	if got, err := applyMySQLTLS("rel:x@tcp(db:3306)/adms", mysqlTLS{}, name); err != nil || got != "rel:x@tcp(db:3306)/adms" {
+		t.Errorf("without TLS options = %q, %v; want the DSN unchanged", got, err)
+	}
+	if _, err := applyMySQLTLS("rel:x@tcp(db:3306)/adms?tls=true", mysqlTLS{CA: ca}, name); err == nil {
+		t.Error("tls= in the DSN plus TLS options must be an error")
+	}
+	// O erro da CA chega a quem chama sem registrar nada.
+	if _, err := applyMySQLTLS("rel:x@tcp(db:3306)/adms", mysqlTLS{CA: ca, SkipVerify: true}, name); err == nil {
+		t.Error("skip-verify with a CA must be an error")
+	}
+}
+
+func TestReadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content, want string
+		wantErr       bool
+	}{
+		{"s3cret\n", "s3cret", false},
+		{"s3cret\r\n", "s3cret", false},
+		{"s3cret\n\n", "s3cret", false},
+		{"s3cret", "s3cret", false},
+		{" s3 cret \n", " s3 cret ", false}, // espaço faz parte da senha
+		{"rel:x@tcp(db:3306)/adms\n", "rel:x@tcp(db:3306)/adms", false},
+		{"\n", "", true},
+		{"", "", true},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, "segredo")
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readSecretFile(path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%d: readSecretFile(%q) = %q, %v; want %q (err %v)", i, tt.content, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if _, err := readSecretFile(filepath.Join(dir, "nao_existe")); err == nil {
+		t.Error("missing file must be an error")
+	}
+}
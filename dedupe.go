@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// deduper remove linhas duplicadas consecutivas: mesmo Paciente e Data - Criação
+// igual (sec <= 0) ou a até sec segundos da anterior. Usado no export e na
+// junção de vários CSVs (--pptx-from), onde a duplicata pode estar na fronteira
+// entre dois arquivos.
+type deduper struct {
+	sec int
+	loc *time.Location // fuso de created: a diferença atravessa o horário de verão certo
+
+	prevPaciente    string
+	prevCreated     string
+	prevCreatedTime time.Time
+	hasPrev         bool
+}
+
+func newDeduper(sec int, loc *time.Location) *deduper {
+	return &deduper{sec: sec, loc: loc}
+}
+
+// isDup diz se a linha repete a anterior. created no formato createdLayout.
+// Linhas que não são duplicatas passam a ser a "anterior".
+func (d *deduper) isDup(paciente, created string) bool {
+	paciente = strings.TrimSpace(paciente)
+	created = strings.TrimSpace(created)
+
+	if d.hasPrev && paciente != "" && created != "" && paciente == d.prevPaciente {
+		// strict compare
+		if d.sec <= 0 {
+			if created == d.prevCreated {
+				return true
+			}
+		} else {
+			// tolerant compare: parse time and consider duplicates if within N seconds
+			curT, okCur := parseCreated(created, d.loc)
+			prevT, okPrev := d.prevCreatedTime, !d.prevCreatedTime.IsZero()
+			if okCur && okPrev {
+				diff := curT.Sub(prevT)
+				if diff < 0 {
+					diff = -diff
+				}
+				if diff <= time.Duration(d.sec)*time.Second {
+					return true
+				}
+			} else {
+				// fallback: if we can't parse, fall back to strict string compare
+				if created == d.prevCreated {
+					return true
+				}
+			}
+		}
+	}
+
+	d.prevPaciente, d.prevCreated = paciente, created
+	d.prevCreatedTime, _ = parseCreated(created, d.loc)
+	d.hasPrev = true
+	return false
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// Fatores da recomendação (--drivers, --pptx-drivers): quanto cada dimensão do
+// atendimento (perguntas 1 a 10 e 19) anda junto com o "Sim" da pergunta 11
+// (Recomendaria esse hospital...?).
+//
+// Importância é a correlação ponto-bisserial entre a nota (Ruim=1, Regular=2,
+// Boa=3, Excelente=4) e o Sim (1) / Não (0), calculada só com quem respondeu as
+// duas perguntas: "Não utilizei" de uma dimensão não tira a pessoa das outras,
+// o que uma regressão logística com todas as perguntas juntas faria. Desempenho
+// é o % de Excelente da dimensão. O cruzamento dos dois mostra onde melhorar
+// primeiro: importância alta e desempenho baixo.
+
+// driverQuestions são as dimensões de serviço; recommendQuestion é o desfecho.
+var driverQuestions = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 19}
+
+func isDriverQuestion(n int) bool {
+	for _, d := range driverQuestions {
+		if n == d {
+			return true
+		}
+	}
+	return false
+}
+
+// driverStat acumula os pares (nota, recomendaria) de uma dimensão: as somas
+// da correlação e o % de Sim entre quem deu Excelente e quem não deu.
+type driverStat struct {
+	N                   int
+	SumX, SumY          float64
+	SumXX, SumYY, SumXY float64
+	TopN, TopYes        int
+	RestN, RestYes      int
+}
+
+// ratingScore: Ruim=1, Regular=2, Boa=3, Excelente=4 (os códigos 1..4 não
+// estão nessa ordem). ok=false para "Não utilizei", Sim/Não e texto livre.
+func ratingScore(v string) (float64, bool) {
+	code, ok := answerCode(replaceValue(v))
+	if !ok {
+		return 0, false
+	}
+	switch code {
+	case "1":
+		return 1, true
+	case "3":
+		return 2, true
+	case "2":
+		return 3, true
+	case "4":
+		return 4, true
+	}
+	return 0, false
+}
+
+// addDrivers soma a linha às estatísticas das dimensões, se ela respondeu a
+// pergunta de recomendação com Sim ou Não.
+func (ac *answerCounts) addDrivers(row []string) {
+	y, found := 0.0, false
+	for _, qc := range ac.Questions {
+		if qc.Number != recommendQuestion {
+			continue
+		}
+		switch code, _ := answerCode(replaceValue(ac.layout.field(row, qc.Index))); code {
+		case "6":
+			y, found = 1, true
+		case "7":
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+	for _, qc := range ac.Questions {
+		if !isDriverQuestion(qc.Number) {
+			continue
+		}
+		x, ok := ratingScore(ac.layout.field(row, qc.Index))
+		if !ok {
+			continue
+		}
+		s := ac.Drivers[qc.Number]
+		if s == nil {
+			s = &driverStat{}
+			ac.Drivers[qc.Number] = s
+		}
+		s.N++
+		s.SumX += x
+		s.SumY += y
+		s.SumXX += x * x
+		s.SumYY += y * y
+		s.SumXY += x * y
+		if x == 4 {
+			s.TopN++
+			s.TopYes += int(y)
+		} else {
+			s.RestN++
+			s.RestYes += int(y)
+		}
+	}
+}
+
+// correlation devolve r de Pearson (ponto-bisserial, já que y é 0/1) e o p
+// bilateral pela transformação de Fisher. ok=false sem variação em x ou y.
+func (s driverStat) correlation() (r, p float64, ok bool) {
+	n := float64(s.N)
+	den := (n*s.SumXX - s.SumX*s.SumX) * (n*s.SumYY - s.SumY*s.SumY)
+	if s.N < 4 || den <= 0 {
+		return 0, 1, false
+	}
+	r = (n*s.SumXY - s.SumX*s.SumY) / math.Sqrt(den)
+	r = math.Max(-1, math.Min(1, r))
+	z := math.Atanh(r) * math.Sqrt(n-3)
+	return r, math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
+
+// Quadrantes do gráfico importância × desempenho, cortados nas médias.
+const (
+	quadPriority  = "prioridade" // importância alta, desempenho baixo
+	quadKeep      = "manter"     // alta, alto
+	quadWatch     = "acompanhar" // baixa, baixo
+	quadSecondary = "secundario" // baixa, alto
+)
+
+type driverResult struct {
+	Question questionCol
+	N        int     // pares nota × recomendaria
+	R, P     float64 // importância
+	TopBox   float64 // desempenho: % Excelente (0..100)
+	TopBoxN  int
+	YesTop   float64 // % Sim entre quem deu Excelente (-1 se ninguém)
+	YesRest  float64 // % Sim entre os demais (-1 se ninguém)
+	Quadrant string
+	LowN     bool
+}
+
+// driverAnalysis ordena as dimensões pela importância, da maior para a menor.
+// ok=false quando não há o que analisar: a pergunta 11 toda Sim (ou toda Não)
+// ou menos de duas dimensões com correlação.
+func driverAnalysis(ac *answerCounts, minN int) (results []driverResult, ok bool) {
+	for i, qc := range ac.Questions {
+		s, found := ac.Drivers[qc.Number]
+		if !found {
+			continue
+		}
+		r, p, corrOK := s.correlation()
+		if !corrOK {
+			continue
+		}
+		res := driverResult{Question: qc, N: s.N, R: r, P: p, YesTop: -1, YesRest: -1, LowN: s.N < minN}
+		res.TopBox, res.TopBoxN, _ = metricValue(metricTopBox, ac.Total[i])
+		if s.TopN > 0 {
+			res.YesTop = float64(s.TopYes) / float64(s.TopN) * 100
+		}
+		if s.RestN > 0 {
+			res.YesRest = float64(s.RestYes) / float64(s.RestN) * 100
+		}
+		results = append(results, res)
+	}
+	if len(results) < 2 {
+		return nil, false
+	}
+	meanR, meanTop := 0.0, 0.0
+	for _, r := range results {
+		meanR += r.R
+		meanTop += r.TopBox
+	}
+	meanR /= float64(len(results))
+	meanTop /= float64(len(results))
+	for i := range results {
+		results[i].Quadrant = quadrant(results[i].R >= meanR, results[i].TopBox >= meanTop)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].R > results[j].R })
+	return results, true
+}
+
+func quadrant(important, performing bool) string {
+	switch {
+	case important && !performing:
+		return quadPriority
+	case important:
+		return quadKeep
+	case !performing:
+		return quadWatch
+	}
+	return quadSecondary
+}
+
+var driversHeader = []string{"posicao", "questao", "titulo", "n", "correlacao", "p", "topbox", "topbox_n", "pct_sim_excelente", "pct_sim_demais", "quadrante", "n_baixo"}
+
+// defaultDriversPath: relatorio_2025_12.csv -> relatorio_2025_12_fatores.csv
+func defaultDriversPath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "_fatores.csv"
+}
+
+// writeDriversCSV grava a análise no dialeto do CSV principal, na ordem de
+// importância.
+func writeDriversCSV(path string, results []driverResult) error {
+	num := func(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
+	pct := func(v float64) string {
+		if v < 0 {
+			return ""
+		}
+		return num(v, 1)
+	}
+	rows := make([][]string, 0, len(results))
+	for i, r := range results {
+		lowN := "0"
+		if r.LowN {
+			lowN = "1"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1), strconv.Itoa(r.Question.Number), r.Question.Title, strconv.Itoa(r.N),
+			num(r.R, 3), num(r.P, 4), num(r.TopBox, 1), strconv.Itoa(r.TopBoxN),
+			pct(r.YesTop), pct(r.YesRest), r.Quadrant, lowN,
+		})
+	}
+	return writeDialectCSV(path, driversHeader, rows)
+}
+
+// driversPathFor resolve --drivers: "auto" grava ao lado do CSV (só com um CSV).
+func driversPathFor(drvFlag string, csvPaths []string) (string, error) {
+	drvFlag = strings.TrimSpace(drvFlag)
+	if !strings.EqualFold(drvFlag, "auto") {
+		return drvFlag, nil
+	}
+	if len(csvPaths) != 1 {
+		return "", fmt.Errorf("--drivers=auto with %d CSVs: pass a file path instead", len(csvPaths))
+	}
+	return defaultDriversPath(csvPaths[0]), nil
+}
+
+// runDrivers grava a análise das respostas do período. Sem o que analisar
+// só avisa: um mês sem nenhum "Não" não é erro.
+func runDrivers(drvFlag string, in *periodAnswers, opts pptxOptions) error {
+	if strings.TrimSpace(drvFlag) == "" {
+		return nil
+	}
+	path, err := driversPathFor(drvFlag, in.Paths)
+	if err != nil {
+		return err
+	}
+	merged, err := in.load()
+	if err != nil {
+		return err
+	}
+	results, ok := driverAnalysis(merged.Counts, opts.MinN)
+	if !ok {
+		slog.Warn("sem análise de fatores: faltam respostas Sim e Não na pergunta de recomendação ou notas nas demais", "question", recommendQuestion, "path", path)
+		return nil
+	}
+	if err := writeDriversCSV(path, results); err != nil {
+		return err
+	}
+	fmt.Printf("OK: fatores da recomendação (%d perguntas) gravados em %s\n", len(results), path)
+	return nil
+}
+
+// driversSlide grava drivers.png e drivers.svg em pngDir: dispersão
+// importância × desempenho e, ao lado, a tabela com a ordem de importância.
+func driversSlide(ac *answerCounts, pngDir string, minN int) (pptxSlideSpec, bool, error) {
+	results, ok := driverAnalysis(ac, minN)
+	if !ok {
+		return pptxSlideSpec{}, false, nil
+	}
+	imgPath := filepath.Join(pngDir, "drivers.png")
+	for _, out := range []struct {
+		path     string
+		provider chart.RendererProvider
+	}{{imgPath, chart.PNG}, {filepath.Join(pngDir, "drivers.svg"), chart.SVG}} {
+		var buf bytes.Buffer
+		if err := driversChart(results, minN).Render(out.provider, &buf); err != nil {
+			return pptxSlideSpec{}, false, fmt.Errorf("render drivers chart: %w", err)
+		}
+		if err := os.WriteFile(out.path, buf.Bytes(), 0o644); err != nil {
+			return pptxSlideSpec{}, false, fmt.Errorf("write %s: %w", filepath.Base(out.path), err)
+		}
+	}
+
+	dm := msgs.Drivers
+	table := &pptxTable{Header: dm.Columns[:]}
+	for i, r := range results {
+		title := fmt.Sprintf("%d. %s", r.Question.Number, strings.TrimRight(r.Question.Title, " :?"))
+		if rs := []rune(title); len(rs) > 30 {
+			title = strings.TrimRight(string(rs[:28]), " ") + "..."
+		}
+		r2 := fmt.Sprintf("%.2f", r.R)
+		if r.LowN {
+			r2 += " *"
+		}
+		table.Rows = append(table.Rows, []string{strconv.Itoa(i + 1), title, r2, fmt.Sprintf("%.0f%%", r.TopBox), dm.Quadrants[r.Quadrant]})
+	}
+	note := dm.Note
+	if minN > 0 {
+		note += " " + fmt.Sprintf(dm.LowN, minN)
+	}
+	return pptxSlideSpec{Title: dm.Title, ImagePath: imgPath, Breakdown: table, Note: note}, true, nil
+}
+
+var (
+	driverPriority = drawing.ColorFromHex("C0392B")
+	driverOther    = drawing.ColorFromHex("2E86C1")
+	driverLowN     = drawing.ColorFromHex("A6A6A6")
+	driverGuide    = drawing.ColorFromHex("7F7F7F")
+)
+
+// driversChart: x = desempenho, y = importância, um ponto por pergunta com o
+// número ao lado; as linhas tracejadas são as médias que cortam os quadrantes.
+func driversChart(results []driverResult, minN int) chart.Chart {
+	xs := make([]float64, len(results))
+	ys := make([]float64, len(results))
+	meanX, meanY := 0.0, 0.0
+	for i, r := range results {
+		xs[i], ys[i] = r.TopBox, r.R
+		meanX += r.TopBox
+		meanY += r.R
+	}
+	meanX /= float64(len(results))
+	meanY /= float64(len(results))
+	xMin, xMax := floatRange(xs, 5)
+	yMin, yMax := floatRange(ys, 0.05)
+	xMin, xMax = math.Max(0, math.Floor(xMin/5)*5), math.Min(100, math.Ceil(xMax/5)*5)
+	yMin, yMax = math.Floor(yMin*20)/20, math.Ceil(yMax*20)/20
+
+	dm := msgs.Drivers
+	const width, height = 1024, 768
+	toPx := func(box chart.Box, x, y float64) (int, int) {
+		px := box.Left + int((x-xMin)/(xMax-xMin)*float64(box.Width()))
+		py := box.Bottom - int((y-yMin)/(yMax-yMin)*float64(box.Height()))
+		return px, py
+	}
+	dot := func(_, _ chart.Range, i int, _, _ float64) drawing.Color {
+		switch {
+		case results[i].LowN:
+			return driverLowN
+		case results[i].Quadrant == quadPriority:
+			return driverPriority
+		}
+		return driverOther
+	}
+	return chart.Chart{
+		Width:      width,
+		Height:     height,
+		Background: chart.Style{Padding: chart.Box{Top: 30, Left: 20, Right: 30, Bottom: 20}},
+		XAxis: chart.XAxis{
+			Name:  dm.XAxis,
+			Range: &chart.ContinuousRange{Min: xMin, Max: xMax},
+			Ticks: ticks(xMin, xMax, 5, "%.0f%%"),
+		},
+		YAxis: chart.YAxis{
+			Name:  dm.YAxis,
+			Range: &chart.ContinuousRange{Min: yMin, Max: yMax},
+			Ticks: ticks(yMin, yMax, 0.05, "%.2f"),
+		},
+		Series: []chart.Series{chart.ContinuousSeries{
+			XValues: xs,
+			YValues: ys,
+			Style:   chart.Style{StrokeWidth: chart.Disabled, DotWidth: 8, DotColorProvider: dot},
+		}},
+		Elements: []chart.Renderable{func(r chart.Renderer, box chart.Box, defaults chart.Style) {
+			// Médias tracejadas.
+			guide := chart.Style{StrokeColor: driverGuide, StrokeWidth: 1.5, StrokeDashArray: []float64{6, 4}}
+			mx, my := toPx(box, meanX, meanY)
+			guide.WriteDrawingOptionsToRenderer(r)
+			r.MoveTo(mx, box.Top)
+			r.LineTo(mx, box.Bottom)
+			r.Stroke()
+			r.MoveTo(box.Left, my)
+			r.LineTo(box.Right, my)
+			r.Stroke()
+			r.ResetStyle()
+
+			// Nome de cada quadrante no canto dele.
+			corner := chart.Style{FontSize: 13, FontColor: driverGuide}.InheritFrom(defaults)
+			const m = 8
+			quads := []struct {
+				q     string
+				b     chart.Box
+				align chart.TextHorizontalAlign
+			}{
+				{quadPriority, chart.Box{Top: box.Top + m, Left: box.Left + m, Right: mx - m, Bottom: box.Top + 30}, chart.TextHorizontalAlignLeft},
+				{quadKeep, chart.Box{Top: box.Top + m, Left: mx + m, Right: box.Right - m, Bottom: box.Top + 30}, chart.TextHorizontalAlignRight},
+				{quadWatch, chart.Box{Top: box.Bottom - 30, Left: box.Left + m, Right: mx - m, Bottom: box.Bottom - m}, chart.TextHorizontalAlignLeft},
+				{quadSecondary, chart.Box{Top: box.Bottom - 30, Left: mx + m, Right: box.Right - m, Bottom: box.Bottom - m}, chart.TextHorizontalAlignRight},
+			}
+			for _, qd := range quads {
+				style := corner
+				style.TextHorizontalAlign = qd.align
+				if qd.q == quadPriority {
+					style.FontColor = driverPriority
+				}
+				chart.Draw.TextWithin(r, dm.Quadrants[qd.q], qd.b, style)
+			}
+
+			// Número da pergunta ao lado do ponto.
+			label := chart.Style{FontSize: 14, FontColor: drawing.ColorBlack}.InheritFrom(defaults)
+			for _, res := range results {
+				px, py := toPx(box, res.TopBox, res.R)
+				chart.Draw.Text(r, fmt.Sprintf("Q%d", res.Question.Number), px+8, py-6, label)
+			}
+		}},
+	}
+}
+
+// ticks marca o eixo de step em step (lo e hi já são múltiplos de step).
+func ticks(lo, hi, step float64, format string) []chart.Tick {
+	var t []chart.Tick
+	for i := 0; ; i++ {
+		v := lo + float64(i)*step
+		if v > hi+step/2 {
+			return t
+		}
+		if math.Abs(v) < step/1e6 {
+			v = 0 // sem "-0.00" por arredondamento
+		}
+		t = append(t, chart.Tick{Value: v, Label: fmt.Sprintf(format, v)})
+	}
+}
+
+// floatRange devolve o mínimo e o máximo de vs com uma folga de pad.
+func floatRange(vs []float64, pad float64) (lo, hi float64) {
+	lo, hi = vs[0], vs[0]
+	for _, v := range vs[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return lo - pad, hi + pad
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+)
+
+func TestRatingScore(t *testing.T) {
+	tests := []struct {
+		in    string
+		score float64
+		ok    bool
+	}{
+		{"Ruim", 1, true},
+		{"Regular", 2, true},
+		{"Boa", 3, true},
+		{"Excelente", 4, true},
+		{"2", 3, true}, // código 2 = Boa: a ordem da nota não é a dos códigos
+		{"Excellent", 4, true},
+		{"Não utilizei", 0, false},
+		{"Sim", 0, false},
+		{"", 0, false},
+		{"ótimo atendimento", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := ratingScore(tt.in)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("ratingScore(%q) = %v, %v; want %v, %v", tt.in, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+// driverCounts monta as contagens a partir de linhas (q1, q2, q3, q4, q11).
+func driverCounts(rows ...[]string) *answerCounts {
+	layout := csvLayout{Floor: -1, Patient: -1, Created: -1, Registrar: -1, Unit: -1}
+	for i, n := range []int{1, 2, 3, 4, recommendQuestion} {
+		layout.Questions = append(layout.Questions, questionCol{Number: n, Index: i, Title: "Q"})
+	}
+	ac := newAnswerCounts(layout)
+	for _, r := range rows {
+		ac.add(r, "")
+	}
+	return ac
+}
+
+func TestDriverCorrelation(t *testing.T) {
+	ac := driverCounts(
+		[]string{"Excelente", "Excelente", "Excelente", "Boa", "Sim"},
+		[]string{"Excelente", "Excelente", "Excelente", "Ruim", "Sim"},
+		[]string{"Boa", "Excelente", "Excelente", "Excelente", "Sim"},
+		[]string{"Boa", "Excelente", "Ruim", "Não utilizei", "Não"},
+		[]string{"Regular", "Excelente", "Ruim", "Não utilizei", "Não"},
+		[]string{"Ruim", "Excelente", "Ruim", "Não utilizei", "Não"},
+	)
+
+	// q1: ponto-bisserial conferido à parte (Pearson com y 0/1); p pela
+	// transformação de Fisher com n = 6.
+	r, p, ok := ac.Drivers[1].correlation()
+	if !ok || !near(r, 0.780869, 1e-6) || !near(p, 0.069603, 1e-6) {
+		t.Errorf("q1: r = %v, p = %v, ok = %v; want 0.780869, 0.069603", r, p, ok)
+	}
+	// q3 separa Sim e Não por completo: r = 1 e p = 0, sem Inf nem NaN.
+	if r, p, ok := ac.Drivers[3].correlation(); !ok || r != 1 || p != 0 {
+		t.Errorf("q3: r = %v, p = %v, ok = %v; want 1, 0", r, p, ok)
+	}
+	// q2 sem variação (todos Excelente) e q4 com 3 pares ficam de fora.
+	if _, _, ok := ac.Drivers[2].correlation(); ok {
+		t.Error("q2 has zero variance: correlation must not be ok")
+	}
+	if s := ac.Drivers[4]; s.N != 3 {
+		t.Errorf("q4: %d pairs, want 3 (Não utilizei is not a pair)", s.N)
+	} else if _, _, ok := s.correlation(); ok {
+		t.Error("q4 has 3 pairs: correlation must not be ok")
+	}
+
+	results, ok := driverAnalysis(ac, 30)
+	if !ok || len(results) != 2 {
+		t.Fatalf("driverAnalysis = %+v, %v; want q3 and q1", results, ok)
+	}
+	if results[0].Question.Number != 3 || results[1].Question.Number != 1 {
+		t.Errorf("order = q%d, q%d; want q3, q1", results[0].Question.Number, results[1].Question.Number)
+	}
+	for _, res := range results {
+		for _, v := range []float64{res.R, res.P, res.TopBox, res.YesTop, res.YesRest} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Errorf("q%d: non-finite value in %+v", res.Question.Number, res)
+			}
+		}
+		if !res.LowN {
+			t.Errorf("q%d: n = %d < 30 must be low n", res.Question.Number, res.N)
+		}
+	}
+	if q1 := results[1]; !near(q1.TopBox, 100.0/3, 1e-9) || q1.YesTop != 100 || q1.YesRest != 25 {
+		t.Errorf("q1: topbox %v, yes among Excelente %v, among the rest %v", q1.TopBox, q1.YesTop, q1.YesRest)
+	}
+
+	// O que vai para o gráfico e para o CSV também não pode ter NaN.
+	var buf bytes.Buffer
+	if err := driversChart(results, 30).Render(chart.SVG, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "NaN") {
+		t.Error("chart has NaN coordinates")
+	}
+	path := filepath.Join(t.TempDir(), "fatores.csv")
+	if err := writeDriversCSV(path, results); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); strings.Contains(string(b), "NaN") {
+		t.Errorf("drivers CSV has NaN:\n%s", b)
+	}
+}
+
+func TestDriverAnalysisNotEnough(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		// Todo mundo recomendaria: sem variação em y.
+		{"all yes", [][]string{
+			{"Excelente", "Boa", "Ruim", "Boa", "Sim"},
+			{"Boa", "Excelente", "Boa", "Ruim", "Sim"},
+			{"Ruim", "Boa", "Excelente", "Boa", "Sim"},
+			{"Regular", "Ruim", "Boa", "Excelente", "Sim"},
+		}},
+		// Só q1 varia: uma dimensão não faz ranking.
+		{"one dimension", [][]string{
+			{"Excelente", "Boa", "Boa", "Boa", "Sim"},
+			{"Excelente", "Boa", "Boa", "Boa", "Sim"},
+			{"Ruim", "Boa", "Boa", "Boa", "Não"},
+			{"Regular", "Boa", "Boa", "Boa", "Não"},
+		}},
+		// Três respostas: poucos pares em todas.
+		{"too few pairs", [][]string{
+			{"Excelente", "Excelente", "Excelente", "Excelente", "Sim"},
+			{"Boa", "Boa", "Boa", "Boa", "Sim"},
+			{"Ruim", "Ruim", "Ruim", "Ruim", "Não"},
+		}},
+		// Sem a pergunta 11 respondida nada entra.
+		{"no recommendation", [][]string{
+			{"Excelente", "Boa", "Ruim", "Boa", ""},
+			{"Ruim", "Excelente", "Boa", "Ruim", "Não utilizei"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if results, ok := driverAnalysis(driverCounts(tt.rows...), 0); ok || results != nil {
+				t.Errorf("driverAnalysis = %+v, %v; want nothing to analyse", results, ok)
+			}
+		})
+	}
+}
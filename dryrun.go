@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// --dry-run: mostra o que a execução faria contra o banco (DSN com a senha
+// mascarada, limites do período, SQL com os parâmetros, COUNT(*) no mesmo
+// WHERE e os arquivos que seriam gravados ou sobrescritos) sem gravar nada.
+// Só lê do banco; webhook, métricas e --publish não rodam.
+
+// plannedPeriod é um período a exportar e o CSV dele.
+type plannedPeriod struct {
+	Start, End time.Time // [Start, End)
+	OutPath    string
+}
+
+// dryRunOptions são as flags que decidem quais arquivos seriam gravados.
+type dryRunOptions struct {
+	Formats  []string
+	PPTX     string
+	KPI      string
+	Heatmap  string
+	Drivers  string
+	Stats    string // só com Compare
+	Compare  bool
+	Alerts   string
+	Metrics  string
+	Publish  string
+	PubTable string
+	Backfill bool           // cada mês na sua pasta, KPI/stats/alertas "auto"
+	Unit     string         // --units: arquivos com a unidade na frente, KPI/stats/alertas "auto"
+	DBLoc    *time.Location // fuso do banco (no --units, o da unidade)
+}
+
+func runDryRun(engine, dsn string, periods []plannedPeriod, opts dryRunOptions) error {
+	fmt.Println("Simulação (--dry-run): nada será gravado.")
+	fmt.Printf("Banco: %s %s\n", engine, maskDSN(engine, dsn))
+	fmt.Printf("Fuso do banco: %s\n", zoneLabel(opts.DBLoc, periods[0].Start))
+
+	db, err := openSource(engine, dsn)
+	if err != nil {
+		return withStage(stageDB, fmt.Errorf("open db: %w", err))
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := pingDB(ctx, db); err != nil {
+		return withStage(stageDB, fmt.Errorf("ping db: %w", err))
+	}
+
+	for i, p := range periods {
+		args := []any{timeArg(engine, p.Start, opts.DBLoc), timeArg(engine, p.End, opts.DBLoc)}
+		fmt.Printf("\nPeríodo: %s -> %s (fim exclusivo)\n", p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339))
+		if i == 0 {
+			// No backfill só muda o parâmetro; a SQL sai uma vez.
+			fmt.Printf("SQL (%s):\n%s\n", engine, indent(interpolate(query, args)))
+		} else {
+			fmt.Printf("Parâmetros: %s\n", strings.Join(quoteArgs(args), ", "))
+		}
+		var n int
+		err := withRetry(ctx, "count", func() error {
+			return db.QueryRowContext(ctx, rebind(engine, countSQL()), args...).Scan(&n)
+		})
+		if err != nil {
+			return withStage(stageQuery, fmt.Errorf("count: %w", err))
+		}
+		fmt.Printf("Linhas no banco (COUNT(*), antes do dedupe): %d\n", n)
+		fmt.Println("Arquivos:")
+		for _, path := range plannedFiles(p, opts) {
+			fmt.Printf("  %s (%s)\n", path, fileState(path))
+		}
+	}
+
+	if opts.Metrics != "" {
+		fmt.Printf("\nMétricas: %s (%s)\n", opts.Metrics, fileState(opts.Metrics))
+	}
+	if opts.Publish != "" {
+		fmt.Printf("Publicação: upsert em %s (%s) - não executado\n", opts.PubTable, opts.Publish)
+	}
+	return nil
+}
+
+// runUnitsDryRun simula cada unidade do --units no seu banco e lista o
+// consolidado. p.OutPath é o --out sem o prefixo da unidade.
+func runUnitsDryRun(units []unitSource, flagTZ string, p plannedPeriod, opts dryRunOptions) error {
+	uopts := opts
+	uopts.Metrics = ""
+	for _, u := range units {
+		loc, err := resolveDBTZ(firstNonEmpty(u.DBTZ, flagTZ), u.Engine, u.DSN)
+		if err != nil {
+			return withStage(stageConfig, err)
+		}
+		fmt.Printf("\n== Unidade %s ==\n", u.Name)
+		uopts.Unit, uopts.DBLoc = u.Name, loc
+		up := p
+		up.OutPath = unitPath(u.Name, p.OutPath)
+		if err := runDryRun(u.Engine, u.DSN, []plannedPeriod{up}, uopts); err != nil {
+			return err
+		}
+	}
+	fmt.Println("\nConsolidado:")
+	files := []string{consolidatedPath(p.OutPath)}
+	if pptx := strings.TrimSpace(opts.PPTX); pptx != "" {
+		pptx = consolidatedPath(pptxPathFor(pptx, p.Start, p.End.Add(-time.Nanosecond)))
+		files = append(files, pptx, strings.TrimSuffix(pptx, filepath.Ext(pptx))+"_png"+string(filepath.Separator))
+	}
+	for _, path := range files {
+		fmt.Printf("  %s (%s)\n", path, fileState(path))
+	}
+	if opts.Metrics != "" {
+		fmt.Printf("\nMétricas: %s (%s)\n", opts.Metrics, fileState(opts.Metrics))
+	}
+	return nil
+}
+
+// plannedFiles lista o que a execução gravaria para p, na ordem em que grava.
+func plannedFiles(p plannedPeriod, opts dryRunOptions) []string {
+	var out []string
+	for _, f := range opts.Formats {
+		out = append(out, formatPath(p.OutPath, f))
+	}
+	last := p.End.Add(-time.Nanosecond)
+	kpi, heat, drv, stats, alerts := opts.KPI, opts.Heatmap, opts.Drivers, opts.Stats, opts.Alerts
+	if opts.Backfill || opts.Unit != "" {
+		kpi, heat, drv, stats, alerts = autoIfSet(kpi), autoIfSet(heat), autoIfSet(drv), autoIfSet(stats), autoIfSet(alerts)
+	}
+	if kpi != "" {
+		if path, err := kpiPathFor(kpi, []string{p.OutPath}); err == nil {
+			out = append(out, path)
+		}
+	}
+	if heat != "" {
+		if path, err := heatmapPathFor(heat, []string{p.OutPath}); err == nil {
+			out = append(out, path)
+		}
+	}
+	if drv != "" {
+		if path, err := driversPathFor(drv, []string{p.OutPath}); err == nil {
+			out = append(out, path)
+		}
+	}
+	if stats != "" && opts.Compare {
+		path := stats
+		if strings.EqualFold(stats, "auto") {
+			path = defaultStatsPath(p.OutPath)
+		}
+		out = append(out, path)
+	}
+	if alerts != "" {
+		if paths, err := alertPaths(alerts, p.OutPath); err == nil {
+			out = append(out, paths...)
+		}
+	}
+	if pptx := strings.TrimSpace(opts.PPTX); pptx != "" {
+		if opts.Backfill {
+			pptx = filepath.Join(filepath.Dir(p.OutPath), defaultPPTXName(p.Start, last))
+		} else if opts.Unit != "" {
+			pptx = unitPath(opts.Unit, pptxPathFor(pptx, p.Start, last))
+		} else if strings.EqualFold(pptx, "auto") {
+			pptx = defaultPPTXName(p.Start, last)
+		}
+		out = append(out, pptx, strings.TrimSuffix(pptx, filepath.Ext(pptx))+"_png"+string(filepath.Separator))
+	}
+	return out
+}
+
+func autoIfSet(flagVal string) string {
+	if strings.TrimSpace(flagVal) == "" {
+		return ""
+	}
+	return "auto"
+}
+
+func fileState(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return "sobrescreve"
+	}
+	return "novo"
+}
+
+// countSQL conta as linhas com o mesmo FROM/JOIN/WHERE da query do export.
+func countSQL() string {
+	from := strings.Index(query, "FROM ")
+	order := strings.Index(query, "ORDER BY")
+	return "SELECT COUNT(*)\n" + strings.TrimSpace(query[from:order])
+}
+
+// interpolate troca os '?' pelos valores, só para exibir; a execução de
+// verdade continua usando parâmetros.
+func interpolate(q string, args []any) string {
+	quoted := quoteArgs(args)
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' && n < len(quoted) {
+			b.WriteString(quoted[n])
+			n++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func quoteArgs(args []any) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = "'" + strings.ReplaceAll(fmt.Sprint(a), "'", "''") + "'"
+	}
+	return out
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
+
+var pgPasswordKV = regexp.MustCompile(`(?i)(password=)('[^']*'|\S+)`)
+
+// maskDSN esconde a senha do DSN que seria usado. DSN que o driver não
+// entende também sai mascarado: é justamente o que se quer conferir no
+// --dry-run.
+func maskDSN(engine, dsn string) string {
+	switch engine {
+	case enginePostgres:
+		if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+			// Redacted só cobre user:senha@; a senha também pode vir na query.
+			if q := u.Query(); q.Has("password") {
+				q.Set("password", "xxxxx")
+				u.RawQuery = q.Encode()
+			}
+			return u.Redacted()
+		}
+		if strings.Contains(dsn, "://") {
+			return maskUserinfo(dsn) // URL com senha sem escape (ex.: '#')
+		}
+		return pgPasswordKV.ReplaceAllString(dsn, "${1}xxxxx")
+	case engineSQLite:
+		return dsn
+	}
+	dsn = ensureParseTime(dsn)
+	if cfg, err := mysql.ParseDSN(dsn); err == nil {
+		if cfg.Passwd == "" {
+			return dsn
+		}
+		return strings.Replace(dsn, ":"+cfg.Passwd+"@", ":xxxxx@", 1)
+	}
+	return maskUserinfo(dsn)
+}
+
+// maskUserinfo troca o que vem entre o primeiro ':' do usuário e o último
+// '@' por xxxxx, sem entender o resto do DSN.
+func maskUserinfo(dsn string) string {
+	start := 0
+	if i := strings.Index(dsn, "://"); i >= 0 {
+		start = i + 3
+	}
+	at := strings.LastIndex(dsn, "@")
+	if at <= start {
+		return dsn
+	}
+	colon := strings.Index(dsn[start:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:start+colon+1] + "xxxxx" + dsn[at:]
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaskDSN(t *testing.T) {
+	const secret = "s3cret"
+	tests := []struct {
+		name, engine, dsn, want string
+	}{
+		{"mysql", engineMySQL, "rel:s3cret@tcp(db:3306)/adms", "rel:xxxxx@tcp(db:3306)/adms?parseTime=true"},
+		{"mysql @ and : in password", engineMySQL, "rel:s3cret:x@y@tcp(db:3306)/adms?loc=Local", "rel:xxxxx@tcp(db:3306)/adms?loc=Local&parseTime=true"},
+		{"mysql invalid DSN", engineMySQL, "rel:s3cret@tcp(db:3306)adms", "rel:xxxxx@tcp(db:3306)adms?parseTime=true"},
+		{"mysql without password", engineMySQL, "rel@tcp(db)/adms", "rel@tcp(db)/adms?parseTime=true"},
+		{"postgres url", enginePostgres, "postgres://rel:s3cret@db:5432/adms?sslmode=require", "postgres://rel:xxxxx@db:5432/adms?sslmode=require"},
+		{"postgres password in query", enginePostgres, "postgresql://rel@db/adms?password=s3cret", "postgresql://rel@db/adms?password=xxxxx"},
+		{"postgres unescaped url", enginePostgres, "postgres://rel:s3#cret@db/adms", "postgres://rel:xxxxx@db/adms"},
+		{"postgres key=value", enginePostgres, "host=db user=rel password=s3cret dbname=adms", "host=db user=rel password=xxxxx dbname=adms"},
+		{"postgres quoted", enginePostgres, "host=db password='s3cret and more' dbname=adms", "host=db password=xxxxx dbname=adms"},
+		{"sqlite", engineSQLite, "sqlite:/dados/adms.db", "sqlite:/dados/adms.db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := maskDSN(tt.engine, tt.dsn)
+			if got != tt.want {
+				t.Errorf("maskDSN = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(got, secret) || strings.Contains(got, "s3#cret") {
+				t.Errorf("password leaked: %q", got)
+			}
+		})
+	}
+}
+
+// A senha lida de --dsn-file ou MYSQL_PASS_FILE passa pelo mesmo maskDSN.
+func TestMaskDSNFromFiles(t *testing.T) {
+	for _, k := range []string{"DB_DRIVER", "DB_DSN", "MYSQL_DSN", "MYSQL_HOST", "MYSQL_PORT", "MYSQL_DB", "MYSQL_USER", "MYSQL_PASS", "MYSQL_PASS_FILE"} {
+		t.Setenv(k, "")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	dsnFile := write("dsn", "rel:Fi1e-s3cret@tcp(db:3306)/adms\r\n")
+	engine, dsn, err := resolveDSN("", dsnFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := maskDSN(engine, dsn); strings.Contains(got, "Fi1e-s3cret") || !strings.Contains(got, "rel:xxxxx@tcp(db:3306)/adms") {
+		t.Errorf("--dsn-file: %q", got)
+	}
+
+	pgFile := write("pg", "postgres://rel:Pg-s3cret@db/adms\n")
+	engine, dsn, err = resolveDSN("", pgFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := maskDSN(engine, dsn); engine != enginePostgres || strings.Contains(got, "Pg-s3cret") {
+		t.Errorf("--dsn-file postgres: %s %q", engine, got)
+	}
+
+	t.Setenv("MYSQL_DB", "adms")
+	t.Setenv("MYSQL_USER", "rel")
+	t.Setenv("MYSQL_PASS_FILE", write("pass", "Pa$$:w@rd\n"))
+	engine, dsn, err = resolveDSN("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := maskDSN(engine, dsn); strings.Contains(got, "Pa$$") || strings.Contains(got, "w@rd") || !strings.Contains(got, "rel:xxxxx@tcp(127.0.0.1:3306)/adms") {
+		t.Errorf("MYSQL_PASS_FILE: %q", got)
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// recordSink recebe os records (layout interno, ver scanRowToStrings) e grava
+// em algum formato. Todos os formatos saem do mesmo loop em main.
+type recordSink interface {
+	Write(record []string) error
+	Close() error
+	Path() string
+}
+
+var supportedFormats = []string{"csv", "jsonl", "parquet"}
+
+func parseFormats(s string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		ok := false
+		for _, sf := range supportedFormats {
+			if f == sf {
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid --format %q (use a comma-separated list of: %s)", f, strings.Join(supportedFormats, ", "))
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("--format is empty")
+	}
+	return out, nil
+}
+
+func hasFormat(formats []string, f string) bool {
+	for _, x := range formats {
+		if x == f {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPath troca a extensão do --out pela do formato.
+// Ex.: relatorio_2025_12.csv -> relatorio_2025_12.jsonl
+func formatPath(outPath, format string) string {
+	if format == "csv" {
+		return outPath
+	}
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "." + format
+}
+
+// openSinks abre um arquivo por formato. loc é o fuso do banco: JSONL e
+// Parquet gravam created como instante.
+func openSinks(outPath string, formats []string, loc *time.Location) ([]recordSink, error) {
+	sinks := make([]recordSink, 0, len(formats))
+	for _, f := range formats {
+		var (
+			s   recordSink
+			err error
+		)
+		p := formatPath(outPath, f)
+		switch f {
+		case "csv":
+			s, err = newCSVSink(p)
+		case "jsonl":
+			s, err = newJSONLSink(p, loc)
+		case "parquet":
+			s, err = newParquetSink(p, loc)
+		}
+		if err != nil {
+			for _, open := range sinks {
+				_ = open.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+type csvSink struct {
+	path string
+	f    *os.File
+	w    *dialectWriter
+}
+
+func newCSVSink(path string) (*csvSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create csv: %w", err)
+	}
+	w, err := dialect.newWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := w.Write(msgs.Header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	return &csvSink{path: path, f: f, w: w}, nil
+}
+
+func (s *csvSink) Write(record []string) error {
+	return s.w.Write(dialect.formatRecord(record))
+}
+
+func (s *csvSink) Close() error {
+	s.w.Flush()
+	if err := s.w.Error(); err != nil {
+		s.f.Close()
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return s.f.Close()
+}
+
+func (s *csvSink) Path() string { return s.path }
+
+// exportRow é o layout "para BI": nomes estáveis em snake_case (iguais às
+// colunas do banco), independentes de --lang, e null no lugar de vazio.
+// andar e as questões de resposta fechada saem como inteiro (o código 1..7);
+// com --replace o rótulo vai em questaoN_rotulo, como no CSV. questao16 e
+// questao20 são texto livre. Valor que não vira código (andar com letra,
+// resposta fora do catálogo) sai null, com o texto em questaoN_rotulo.
+type exportRow struct {
+	Andar           *int32     `json:"andar" parquet:"andar,optional"`
+	Paciente        *string    `json:"paciente" parquet:"paciente,optional"`
+	Questao1        *int32     `json:"questao1" parquet:"questao1,optional"`
+	Questao1Rotulo  *string    `json:"questao1_rotulo" parquet:"questao1_rotulo,optional"`
+	Questao2        *int32     `json:"questao2" parquet:"questao2,optional"`
+	Questao2Rotulo  *string    `json:"questao2_rotulo" parquet:"questao2_rotulo,optional"`
+	Questao3        *int32     `json:"questao3" parquet:"questao3,optional"`
+	Questao3Rotulo  *string    `json:"questao3_rotulo" parquet:"questao3_rotulo,optional"`
+	Questao4        *int32     `json:"questao4" parquet:"questao4,optional"`
+	Questao4Rotulo  *string    `json:"questao4_rotulo" parquet:"questao4_rotulo,optional"`
+	Questao5        *int32     `json:"questao5" parquet:"questao5,optional"`
+	Questao5Rotulo  *string    `json:"questao5_rotulo" parquet:"questao5_rotulo,optional"`
+	Questao6        *int32     `json:"questao6" parquet:"questao6,optional"`
+	Questao6Rotulo  *string    `json:"questao6_rotulo" parquet:"questao6_rotulo,optional"`
+	Questao7        *int32     `json:"questao7" parquet:"questao7,optional"`
+	Questao7Rotulo  *string    `json:"questao7_rotulo" parquet:"questao7_rotulo,optional"`
+	Questao8        *int32     `json:"questao8" parquet:"questao8,optional"`
+	Questao8Rotulo  *string    `json:"questao8_rotulo" parquet:"questao8_rotulo,optional"`
+	Questao9        *int32     `json:"questao9" parquet:"questao9,optional"`
+	Questao9Rotulo  *string    `json:"questao9_rotulo" parquet:"questao9_rotulo,optional"`
+	Questao10       *int32     `json:"questao10" parquet:"questao10,optional"`
+	Questao10Rotulo *string    `json:"questao10_rotulo" parquet:"questao10_rotulo,optional"`
+	Questao11       *int32     `json:"questao11" parquet:"questao11,optional"`
+	Questao11Rotulo *string    `json:"questao11_rotulo" parquet:"questao11_rotulo,optional"`
+	Questao12       *int32     `json:"questao12" parquet:"questao12,optional"`
+	Questao12Rotulo *string    `json:"questao12_rotulo" parquet:"questao12_rotulo,optional"`
+	Questao13       *int32     `json:"questao13" parquet:"questao13,optional"`
+	Questao13Rotulo *string    `json:"questao13_rotulo" parquet:"questao13_rotulo,optional"`
+	Questao14       *int32     `json:"questao14" parquet:"questao14,optional"`
+	Questao14Rotulo *string    `json:"questao14_rotulo" parquet:"questao14_rotulo,optional"`
+	Questao15       *int32     `json:"questao15" parquet:"questao15,optional"`
+	Questao15Rotulo *string    `json:"questao15_rotulo" parquet:"questao15_rotulo,optional"`
+	Questao16       *string    `json:"questao16" parquet:"questao16,optional"`
+	Questao17       *int32     `json:"questao17" parquet:"questao17,optional"`
+	Questao17Rotulo *string    `json:"questao17_rotulo" parquet:"questao17_rotulo,optional"`
+	Questao18       *int32     `json:"questao18" parquet:"questao18,optional"`
+	Questao18Rotulo *string    `json:"questao18_rotulo" parquet:"questao18_rotulo,optional"`
+	Questao19       *int32     `json:"questao19" parquet:"questao19,optional"`
+	Questao19Rotulo *string    `json:"questao19_rotulo" parquet:"questao19_rotulo,optional"`
+	Questao20       *string    `json:"questao20" parquet:"questao20,optional"`
+	Created         *time.Time `json:"created" parquet:"created,optional"`
+	Cadastrador     *string    `json:"cadastrador" parquet:"cadastrador,optional"`
+}
+
+// toExportRow converte o record; dirty conta os valores que não viraram
+// código. Uma linha suja no banco não interrompe o export (o CSV nunca parou
+// por conteúdo): quem grava avisa no fim com o total.
+func toExportRow(record []string, loc *time.Location) (row exportRow, dirty int, err error) {
+	if len(record) < 24 {
+		return exportRow{}, 0, fmt.Errorf("record has %d fields; expected 24", len(record))
+	}
+	str := func(i int) *string {
+		v := strings.TrimSpace(record[i])
+		if v == "" {
+			return nil
+		}
+		return &v
+	}
+	row = exportRow{
+		Paciente:    str(1),
+		Questao16:   str(17),
+		Questao20:   str(21),
+		Cadastrador: str(23),
+	}
+	if v := str(0); v != nil {
+		if n, ok := parseInt32(*v); ok {
+			row.Andar = &n
+		} else {
+			dirty++
+		}
+	}
+	qs := []struct {
+		n     int
+		code  **int32
+		label **string
+	}{
+		{1, &row.Questao1, &row.Questao1Rotulo}, {2, &row.Questao2, &row.Questao2Rotulo},
+		{3, &row.Questao3, &row.Questao3Rotulo}, {4, &row.Questao4, &row.Questao4Rotulo},
+		{5, &row.Questao5, &row.Questao5Rotulo}, {6, &row.Questao6, &row.Questao6Rotulo},
+		{7, &row.Questao7, &row.Questao7Rotulo}, {8, &row.Questao8, &row.Questao8Rotulo},
+		{9, &row.Questao9, &row.Questao9Rotulo}, {10, &row.Questao10, &row.Questao10Rotulo},
+		{11, &row.Questao11, &row.Questao11Rotulo}, {12, &row.Questao12, &row.Questao12Rotulo},
+		{13, &row.Questao13, &row.Questao13Rotulo}, {14, &row.Questao14, &row.Questao14Rotulo},
+		{15, &row.Questao15, &row.Questao15Rotulo}, {17, &row.Questao17, &row.Questao17Rotulo},
+		{18, &row.Questao18, &row.Questao18Rotulo}, {19, &row.Questao19, &row.Questao19Rotulo},
+	}
+	for _, q := range qs {
+		v := str(1 + q.n)
+		if v == nil {
+			continue
+		}
+		if n, ok := parseInt32(*v); ok {
+			*q.code = &n
+			continue
+		}
+		// Rótulo (--replace): o código vem do catálogo e o texto fica ao lado.
+		*q.label = v
+		if code, ok := answerCode(*v); ok {
+			n, _ := parseInt32(code)
+			*q.code = &n
+		} else {
+			dirty++
+		}
+	}
+	if t, ok := parseCreated(record[22], loc); ok {
+		row.Created = &t
+	}
+	return row, dirty, nil
+}
+
+// warnDirty avisa, ao fechar um arquivo tipado, quantos valores saíram null.
+func warnDirty(path string, dirty int) {
+	if dirty > 0 {
+		slog.Warn("valores sem código (andar não numérico ou resposta fora do catálogo) gravados como null", "path", path, "values", dirty)
+	}
+}
+
+// parseInt32 lê códigos como "3", " 3 " ou "3.0" (o banco às vezes devolve
+// decimal), igual a replaceValue.
+func parseInt32(s string) (int32, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, ".,"); i > 0 && strings.Trim(s[i+1:], "0") == "" {
+		s = s[:i]
+	}
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(n), true
+}
+
+type jsonlSink struct {
+	path  string
+	f     *os.File
+	bw    *bufio.Writer
+	enc   *json.Encoder
+	loc   *time.Location
+	dirty int
+}
+
+func newJSONLSink(path string, loc *time.Location) (*jsonlSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create jsonl: %w", err)
+	}
+	bw := bufio.NewWriter(f)
+	enc := json.NewEncoder(bw) // Encode já termina cada objeto com '\n'
+	enc.SetEscapeHTML(false)
+	return &jsonlSink{path: path, f: f, bw: bw, enc: enc, loc: loc}, nil
+}
+
+func (s *jsonlSink) Write(record []string) error {
+	row, dirty, err := toExportRow(record, s.loc)
+	if err != nil {
+		return err
+	}
+	s.dirty += dirty
+	return s.enc.Encode(row)
+}
+
+func (s *jsonlSink) Close() error {
+	if err := s.bw.Flush(); err != nil {
+		s.f.Close()
+		return fmt.Errorf("flush jsonl: %w", err)
+	}
+	warnDirty(s.path, s.dirty)
+	return s.f.Close()
+}
+
+func (s *jsonlSink) Path() string { return s.path }
+
+type parquetSink struct {
+	path  string
+	f     *os.File
+	w     *parquet.GenericWriter[exportRow]
+	loc   *time.Location
+	dirty int
+}
+
+func newParquetSink(path string, loc *time.Location) (*parquetSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create parquet: %w", err)
+	}
+	w := parquet.NewGenericWriter[exportRow](f, parquet.Compression(&parquet.Snappy))
+	return &parquetSink{path: path, f: f, w: w, loc: loc}, nil
+}
+
+func (s *parquetSink) Write(record []string) error {
+	row, dirty, err := toExportRow(record, s.loc)
+	if err != nil {
+		return err
+	}
+	s.dirty += dirty
+	if _, err := s.w.Write([]exportRow{row}); err != nil {
+		return fmt.Errorf("write parquet: %w", err)
+	}
+	return nil
+}
+
+func (s *parquetSink) Close() error {
+	if err := s.w.Close(); err != nil {
+		s.f.Close()
+		return fmt.Errorf("close parquet: %w", err)
+	}
+	warnDirty(s.path, s.dirty)
+	return s.f.Close()
+}
+
+func (s *parquetSink) Path() string { return s.path }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// exportRecord monta um record no layout interno com as questões dadas
+// (índice 0 = questao1).
+func exportRecord(andar string, qs map[int]string) []string {
+	rec := make([]string, 24)
+	rec[0] = andar
+	rec[1] = "Maria"
+	for i, v := range qs {
+		rec[2+i] = v
+	}
+	rec[22] = "2025-12-03 14:05:00"
+	rec[23] = "Recepção"
+	return rec
+}
+
+func TestToExportRow(t *testing.T) {
+	row, dirty, err := toExportRow(exportRecord("3", map[int]string{0: "4", 1: "2.0", 2: "Excelente", 3: "Poor", 15: "muito bom", 19: "  "}), time.UTC)
+	if err != nil || dirty != 0 {
+		t.Fatal(dirty, err)
+	}
+	if row.Andar == nil || *row.Andar != 3 {
+		t.Errorf("andar = %v", row.Andar)
+	}
+	if *row.Questao1 != 4 || row.Questao1Rotulo != nil || *row.Questao2 != 2 {
+		t.Errorf("codes: questao1=%v/%v questao2=%v", *row.Questao1, row.Questao1Rotulo, *row.Questao2)
+	}
+	// Com --replace o código sai do rótulo (em qualquer idioma) e o texto fica ao lado.
+	if *row.Questao3 != 4 || *row.Questao3Rotulo != "Excelente" || *row.Questao4 != 1 || *row.Questao4Rotulo != "Poor" {
+		t.Errorf("labels: questao3=%v/%v questao4=%v/%v", *row.Questao3, *row.Questao3Rotulo, *row.Questao4, *row.Questao4Rotulo)
+	}
+	if row.Questao5 != nil || row.Questao5Rotulo != nil || row.Questao20 != nil || *row.Questao16 != "muito bom" {
+		t.Error("empty questions must be null and questao16 stays text")
+	}
+
+	// Linha suja: o valor tipado fica null, o texto vai para o rótulo e o
+	// export segue.
+	row, dirty, err = toExportRow(exportRecord("3A", map[int]string{0: "4", 4: "talvez"}), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirty != 2 || row.Andar != nil || *row.Questao1 != 4 {
+		t.Errorf("dirty = %d, andar = %v, questao1 = %v", dirty, row.Andar, row.Questao1)
+	}
+	if row.Questao5 != nil || row.Questao5Rotulo == nil || *row.Questao5Rotulo != "talvez" {
+		t.Errorf("questao5 = %v / %v, want null / talvez", row.Questao5, row.Questao5Rotulo)
+	}
+	if _, _, err := toExportRow(make([]string, 10), time.UTC); err == nil {
+		t.Error("short record: expected error")
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo") // created é o instante no fuso do banco
+	dir := t.TempDir()
+	sink, err := newParquetSink(filepath.Join(dir, "r.parquet"), sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := [][]string{
+		exportRecord("3", map[int]string{0: "4", 2: "Sim", 15: "ok"}),
+		exportRecord("", nil),
+	}
+	for _, r := range recs {
+		if err := sink.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(sink.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, _ := f.Stat()
+	pf, err := parquet.OpenFile(f, st.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]parquet.Kind{}
+	for _, col := range pf.Schema().Fields() {
+		if !col.Optional() {
+			t.Errorf("column %s is not optional", col.Name())
+		}
+		types[col.Name()] = col.Type().Kind()
+	}
+	want := map[string]parquet.Kind{
+		"andar":           parquet.Int32,
+		"questao1":        parquet.Int32,
+		"questao1_rotulo": parquet.ByteArray,
+		"questao16":       parquet.ByteArray,
+		"questao19":       parquet.Int32,
+		"questao20":       parquet.ByteArray,
+		"created":         parquet.Int64,
+	}
+	for name, kind := range want {
+		if types[name] != kind {
+			t.Errorf("column %s: kind %v, want %v", name, types[name], kind)
+		}
+	}
+	if _, ok := types["questao16_rotulo"]; ok {
+		t.Error("free-text questions have no label column")
+	}
+
+	rows, err := parquet.ReadFile[exportRow](sink.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d rows, want 2", len(rows))
+	}
+	r := rows[0]
+	if *r.Andar != 3 || *r.Questao1 != 4 || *r.Questao3 != 6 || *r.Questao3Rotulo != "Sim" || *r.Questao16 != "ok" {
+		t.Errorf("row 0 = %+v", r)
+	}
+	if !r.Created.Equal(time.Date(2025, 12, 3, 14, 5, 0, 0, sp)) {
+		t.Errorf("created = %v", r.Created)
+	}
+	if rows[1].Andar != nil || rows[1].Questao1 != nil {
+		t.Errorf("row 1 must have null andar/questao1: %+v", rows[1])
+	}
+}
+
+func TestJSONLTyped(t *testing.T) {
+	sink, err := newJSONLSink(filepath.Join(t.TempDir(), "r.jsonl"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(exportRecord("5", map[int]string{0: "Ruim"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(exportRecord("UTI", map[int]string{0: "péssimo"})); err != nil {
+		t.Fatalf("dirty row must not fail the export: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(sink.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var got, dirty map[string]any
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["andar"] != float64(5) || got["questao1"] != float64(1) || got["questao1_rotulo"] != "Ruim" || got["questao2"] != nil {
+		t.Errorf("jsonl = %v", got)
+	}
+	if err := dec.Decode(&dirty); err != nil {
+		t.Fatal(err)
+	}
+	if dirty["andar"] != nil || dirty["questao1"] != nil || dirty["questao1_rotulo"] != "péssimo" {
+		t.Errorf("dirty row = %v", dirty)
+	}
+}
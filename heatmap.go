@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// Mapa de calor pergunta × andar (--heatmap, --pptx-heatmap): uma figura só
+// para a diretoria ver onde os problemas se concentram. Linhas são as
+// perguntas, colunas o hospital inteiro e cada andar (num_andar); a cor é o %
+// de Excelente (top-box) nas perguntas de escala e o % de Sim nas de Sim/Não,
+// e cada célula traz o n. Abaixo de --min-n a célula sai em cinza.
+//
+// As faixas de cor são fixas (heatBands), não o menor e o maior % do mês: o
+// mesmo verde quer dizer o mesmo % em qualquer relatório, e um mês em que
+// todos os andares foram bem não aparece metade vermelho.
+
+// heatCell é uma célula do mapa; ok=false quando o andar não respondeu.
+type heatCell struct {
+	Pct float64 // 0..100
+	N   int
+	OK  bool
+}
+
+type heatRow struct {
+	Question questionCol
+	Metric   string // metricTopBox ou metricYes
+	Cells    []heatCell
+}
+
+// heatmap: Floors[j] é a coluna j+1; a coluna 0 é o geral.
+type heatmap struct {
+	Floors []string
+	Rows   []heatRow
+}
+
+func buildHeatmap(ac *answerCounts) heatmap {
+	floors := make([]string, 0, len(ac.ByFloor))
+	for f := range ac.ByFloor {
+		if f != "" { // sem andar só entra no geral
+			floors = append(floors, f)
+		}
+	}
+	sort.Slice(floors, func(i, j int) bool { return floorLess(floors[i], floors[j]) })
+
+	hm := heatmap{Floors: floors}
+	for i, qc := range ac.Questions {
+		metric := metricYes
+		if isRatingQuestion(ac.Total[i]) {
+			metric = metricTopBox
+		}
+		pct, n, ok := metricValue(metric, ac.Total[i])
+		if !ok {
+			continue
+		}
+		row := heatRow{Question: qc, Metric: metric, Cells: []heatCell{{Pct: pct, N: n, OK: true}}}
+		for _, f := range floors {
+			var c heatCell
+			c.Pct, c.N, c.OK = metricValue(metric, ac.ByFloor[f][i])
+			row.Cells = append(row.Cells, c)
+		}
+		hm.Rows = append(hm.Rows, row)
+	}
+	return hm
+}
+
+// floorLess ordena os andares pelo número (2 antes de 10); no consolidado do
+// --units ("principal/3") agrupa por unidade.
+func floorLess(a, b string) bool {
+	ua, fa := splitUnitFloor(a)
+	ub, fb := splitUnitFloor(b)
+	if ua != ub {
+		return ua < ub
+	}
+	na, errA := strconv.Atoi(fa)
+	nb, errB := strconv.Atoi(fb)
+	switch {
+	case errA == nil && errB == nil:
+		return na < nb
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return fa < fb
+}
+
+func splitUnitFloor(key string) (unit, floor string) {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+// heatBands: até Red o % é vermelho, a partir de Green é verde e, entre os
+// dois, passa pelo amarelo. Cada indicador tem a sua faixa: o % de Sim costuma
+// ficar bem acima do top-box e, numa faixa só, sairia tudo verde.
+var heatBands = map[string]struct{ Red, Green float64 }{
+	metricTopBox: {Red: 40, Green: 80},
+	metricYes:    {Red: 70, Green: 95},
+}
+
+// metrics lista os indicadores presentes nas linhas, top-box primeiro.
+func (hm heatmap) metrics() []string {
+	var out []string
+	for _, metric := range []string{metricTopBox, metricYes} {
+		for _, r := range hm.Rows {
+			if r.Metric == metric {
+				out = append(out, metric)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// title: "% Excelente", "% Sim" ou os dois, conforme as perguntas do mapa.
+func (hm heatmap) title() string {
+	var names []string
+	for _, metric := range hm.metrics() {
+		names = append(names, "% "+msgs.metricName(metric))
+	}
+	return fmt.Sprintf(msgs.Heatmap.Title, strings.Join(names, " / "))
+}
+
+// defaultHeatmapPath: relatorio_2025_12.csv -> relatorio_2025_12_mapa_calor.csv
+func defaultHeatmapPath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "_mapa_calor.csv"
+}
+
+// writeHeatmapCSV grava a matriz: uma linha por pergunta, % e n de cada
+// coluna (geral e andares), no dialeto do CSV principal.
+func writeHeatmapCSV(path string, hm heatmap) error {
+	header := []string{"questao", "titulo", "indicador", "geral", "n_geral"}
+	for _, fl := range hm.Floors {
+		header = append(header, fl, "n_"+fl)
+	}
+	rows := make([][]string, 0, len(hm.Rows))
+	for _, r := range hm.Rows {
+		rec := []string{strconv.Itoa(r.Question.Number), r.Question.Title, r.Metric}
+		for _, c := range r.Cells {
+			if !c.OK {
+				rec = append(rec, "", "0")
+				continue
+			}
+			rec = append(rec, strconv.FormatFloat(c.Pct, 'f', 1, 64), strconv.Itoa(c.N))
+		}
+		rows = append(rows, rec)
+	}
+	return writeDialectCSV(path, header, rows)
+}
+
+// heatmapPathFor resolve --heatmap: "auto" grava ao lado do CSV (só com um CSV).
+func heatmapPathFor(heatFlag string, csvPaths []string) (string, error) {
+	heatFlag = strings.TrimSpace(heatFlag)
+	if !strings.EqualFold(heatFlag, "auto") {
+		return heatFlag, nil
+	}
+	if len(csvPaths) != 1 {
+		return "", fmt.Errorf("--heatmap=auto with %d CSVs: pass a file path instead", len(csvPaths))
+	}
+	return defaultHeatmapPath(csvPaths[0]), nil
+}
+
+// runHeatmap grava a matriz das respostas do período.
+func runHeatmap(heatFlag string, in *periodAnswers) error {
+	if strings.TrimSpace(heatFlag) == "" {
+		return nil
+	}
+	path, err := heatmapPathFor(heatFlag, in.Paths)
+	if err != nil {
+		return err
+	}
+	merged, err := in.load()
+	if err != nil {
+		return err
+	}
+	hm := buildHeatmap(merged.Counts)
+	if err := writeHeatmapCSV(path, hm); err != nil {
+		return err
+	}
+	fmt.Printf("OK: mapa de calor (%d perguntas x %d andares) gravado em %s\n", len(hm.Rows), len(hm.Floors), path)
+	return nil
+}
+
+// heatmapSlide grava heatmap.png (para o slide) e heatmap.svg (para impressão
+// e web) em pngDir. ok=false sem andares para comparar.
+func heatmapSlide(ac *answerCounts, pngDir string, minN int) (pptxSlideSpec, bool, error) {
+	hm := buildHeatmap(ac)
+	if len(hm.Rows) == 0 || len(hm.Floors) < 2 {
+		return pptxSlideSpec{}, false, nil
+	}
+	imgPath := filepath.Join(pngDir, "heatmap.png")
+	for _, out := range []struct {
+		path     string
+		provider chart.RendererProvider
+	}{{imgPath, chart.PNG}, {filepath.Join(pngDir, "heatmap.svg"), chart.SVG}} {
+		b, err := renderHeatmap(hm, minN, out.provider)
+		if err != nil {
+			return pptxSlideSpec{}, false, fmt.Errorf("render heatmap: %w", err)
+		}
+		if err := os.WriteFile(out.path, b, 0o644); err != nil {
+			return pptxSlideSpec{}, false, fmt.Errorf("write %s: %w", filepath.Base(out.path), err)
+		}
+	}
+	return pptxSlideSpec{Title: hm.title(), ImagePath: imgPath}, true, nil
+}
+
+var (
+	heatRed   = drawing.ColorFromHex("C0392B")
+	heatAmber = drawing.ColorFromHex("F1C40F")
+	heatGreen = drawing.ColorFromHex("27AE60")
+	heatGrey  = drawing.ColorFromHex("D9D9D9")
+	heatLine  = drawing.ColorFromHex("FFFFFF")
+)
+
+// heatColor vai do vermelho (até lo) ao verde (a partir de hi), passando pelo amarelo.
+func heatColor(pct, lo, hi float64) drawing.Color {
+	t := 1.0
+	if hi > lo {
+		t = math.Max(0, math.Min(1, (pct-lo)/(hi-lo)))
+	}
+	from, to := heatRed, heatAmber
+	if t > 0.5 {
+		from, to, t = heatAmber, heatGreen, t-0.5
+	}
+	t *= 2
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5) }
+	return drawing.Color{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: 255}
+}
+
+// renderHeatmap desenha a matriz com o renderer do go-chart (PNG ou SVG).
+func renderHeatmap(hm heatmap, minN int, provider chart.RendererProvider) ([]byte, error) {
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, err
+	}
+	const (
+		labelW  = 520 // nomes das perguntas
+		headerH = 56
+		rowH    = 46
+		legendH = 60
+		gap     = 8 // entre o geral e os andares
+		pad     = 10
+	)
+	cols := len(hm.Floors) + 1
+	cellW := (1600 - labelW - gap - 2*pad) / cols
+	if cellW < 64 {
+		cellW = 64
+	}
+	width := labelW + gap + cols*cellW + 2*pad
+	height := headerH + len(hm.Rows)*rowH + legendH + 2*pad
+
+	r, err := provider(width, height)
+	if err != nil {
+		return nil, err
+	}
+	// rect: chart.NewBox recebe top, left, right, bottom; aqui vai x antes de y.
+	rect := func(left, top, right, bottom int) chart.Box {
+		return chart.Box{Top: top, Left: left, Right: right, Bottom: bottom}
+	}
+	chart.Draw.Box(r, rect(0, 0, width, height), chart.Style{FillColor: drawing.ColorWhite, StrokeColor: drawing.ColorWhite})
+
+	text := func(s string, b chart.Box, size float64, color drawing.Color, align chart.TextHorizontalAlign) {
+		chart.Draw.TextWithin(r, s, b, chart.Style{
+			Font: font, FontSize: size, FontColor: color,
+			TextHorizontalAlign: align, TextVerticalAlign: chart.TextVerticalAlignMiddle,
+			TextWrap: chart.TextWrapNone,
+		})
+	}
+	colLeft := func(c int) int {
+		x := pad + labelW + c*cellW
+		if c > 0 {
+			x += gap
+		}
+		return x
+	}
+
+	// Cabeçalho: ANDAR no canto, geral e os andares.
+	text(msgs.Header[0], rect(pad, pad, pad+labelW-10, pad+headerH), 14, drawing.ColorBlack, chart.TextHorizontalAlignRight)
+	for c := 0; c < cols; c++ {
+		label := msgs.Heatmap.Overall
+		if c > 0 {
+			label = hm.Floors[c-1]
+		}
+		text(label, rect(colLeft(c), pad, colLeft(c)+cellW, pad+headerH), 14, drawing.ColorBlack, chart.TextHorizontalAlignCenter)
+	}
+
+	present := hm.metrics()
+	var ranges []string
+	for _, metric := range present {
+		b := heatBands[metric]
+		ranges = append(ranges, fmt.Sprintf(msgs.Heatmap.Range, msgs.metricName(metric), b.Red, b.Green))
+	}
+	labelStyle := chart.Style{Font: font, FontSize: 13}
+	for i, row := range hm.Rows {
+		top := pad + headerH + i*rowH
+		title := fmt.Sprintf("%d. %s", row.Question.Number, strings.TrimRight(row.Question.Title, " :?"))
+		// Com os dois indicadores no mapa, as linhas de Sim/Não dizem o que é o %.
+		suffix := ""
+		if len(present) > 1 && row.Metric != present[0] {
+			suffix = " (% " + msgs.metricName(row.Metric) + ")"
+		}
+		// Corta pelo tamanho desenhado: títulos em caixa alta ocupam mais.
+		for rs := []rune(title); chart.Draw.MeasureText(r, title+suffix, labelStyle).Width() > labelW-20 && len(rs) > 4; {
+			rs = rs[:len(rs)-1]
+			title = strings.TrimRight(string(rs), " ") + "..."
+		}
+		title += suffix
+		text(title, rect(pad, top, pad+labelW-10, top+rowH), 13, drawing.ColorBlack, chart.TextHorizontalAlignLeft)
+		band := heatBands[row.Metric]
+		for c, cell := range row.Cells {
+			box := rect(colLeft(c), top, colLeft(c)+cellW, top+rowH)
+			fill, fg := drawing.ColorWhite, drawing.ColorBlack
+			switch {
+			case !cell.OK:
+			case cell.N < minN:
+				fill, fg = heatGrey, drawing.ColorFromHex("595959")
+			default:
+				fill = heatColor(cell.Pct, band.Red, band.Green)
+			}
+			chart.Draw.Box(r, box, chart.Style{FillColor: fill, StrokeColor: heatLine, StrokeWidth: 2})
+			if !cell.OK {
+				text("-", box, 13, drawing.ColorFromHex("7F7F7F"), chart.TextHorizontalAlignCenter)
+				continue
+			}
+			upper := rect(box.Left, box.Top+2, box.Right, box.Top+rowH*3/5)
+			lower := rect(box.Left, box.Top+rowH*3/5-2, box.Right, box.Bottom-2)
+			text(fmt.Sprintf("%.0f%%", cell.Pct), upper, 14, fg, chart.TextHorizontalAlignCenter)
+			text(fmt.Sprintf("n=%d", cell.N), lower, 10, fg, chart.TextHorizontalAlignCenter)
+		}
+	}
+
+	legend := fmt.Sprintf(msgs.Heatmap.Legend, strings.Join(ranges, "; "))
+	if minN > 0 {
+		legend += " " + fmt.Sprintf(msgs.Heatmap.LowN, minN)
+	}
+	legendTop := pad + headerH + len(hm.Rows)*rowH
+	text(legend, rect(pad, legendTop, width-pad, legendTop+legendH), 13, drawing.ColorFromHex("404040"), chart.TextHorizontalAlignLeft)
+
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
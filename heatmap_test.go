@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+func TestHeatColorFixedBands(t *testing.T) {
+	b := heatBands[metricTopBox]
+	tests := []struct {
+		pct  float64
+		want drawing.Color
+	}{
+		{0, heatRed},
+		{b.Red, heatRed},
+		{(b.Red + b.Green) / 2, heatAmber},
+		{b.Green, heatGreen},
+		{100, heatGreen},
+	}
+	for _, tt := range tests {
+		if got := heatColor(tt.pct, b.Red, b.Green); got != tt.want {
+			t.Errorf("heatColor(%g) = %v, want %v", tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestHeatmapTitle(t *testing.T) {
+	rating := map[string]int{"Excelente": 8, "Boa": 2}
+	yesNo := map[string]int{"Sim": 9, "Não": 1}
+	build := func(counts ...map[string]int) heatmap {
+		ac := &answerCounts{ByFloor: map[string][]map[string]int{}}
+		for i, c := range counts {
+			ac.Questions = append(ac.Questions, questionCol{Number: i + 1})
+			ac.Total = append(ac.Total, c)
+		}
+		return buildHeatmap(ac)
+	}
+	tests := []struct {
+		name string
+		hm   heatmap
+		want string
+	}{
+		{"rating only", build(rating), "Mapa de calor: % Excelente por pergunta e andar"},
+		{"yes/no only", build(yesNo), "Mapa de calor: % Sim por pergunta e andar"},
+		{"both", build(yesNo, rating), "Mapa de calor: % Excelente / % Sim por pergunta e andar"},
+	}
+	for _, tt := range tests {
+		if got := tt.hm.title(); got != tt.want {
+			t.Errorf("%s: title = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if hm := build(yesNo, rating); hm.Rows[0].Metric != metricYes || hm.Rows[1].Metric != metricTopBox {
+		t.Errorf("row metrics = %s, %s", hm.Rows[0].Metric, hm.Rows[1].Metric)
+	}
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// messages reúne os textos que aparecem nas saídas (CSV, PNGs e PPTX).
+// Os logs e mensagens de erro continuam como estão; o que muda com --lang
+// é só o que vai para o relatório.
+type messages struct {
+	// Header segue o layout do record (ver scanRowToStrings).
+	Header []string
+	// Unit é a primeira coluna do CSV consolidado do --units.
+	Unit string
+	// Answers mapeia os códigos 1..7 das questões para o rótulo.
+	Answers map[string]string
+	// Months de janeiro a dezembro.
+	Months [12]string
+	// ReportTitle recebe nome do mês e ano. Ex.: "Relatório %s/%04d".
+	ReportTitle string
+	// ReportRangeTitle recebe mês/ano inicial e mês/ano final (trimestre, ano...).
+	ReportRangeTitle string
+	// ReportDaysTitle recebe data inicial e final (semana, últimos N dias...),
+	// formatadas com DateLayout.
+	ReportDaysTitle string
+	DateLayout      string
+	// PeriodRange recebe data inicial e final (placeholder "period" do template).
+	PeriodRange string
+	// ChartN e ChartLowN são o título de cada gráfico: n e, abaixo de --min-n,
+	// o aviso de amostra pequena (n, mínimo).
+	ChartN    string
+	ChartLowN string
+	// Slides de abertura do deck (capa, metodologia e resumo executivo).
+	Intro introMessages
+	// Nota de significância em cada slide (--compare).
+	Compare compareMessages
+	// Slide do mapa de calor pergunta × andar (--pptx-heatmap).
+	Heatmap heatmapMessages
+	// Slide dos fatores da recomendação (--pptx-drivers).
+	Drivers driversMessages
+	// Metas (--targets): nomes dos indicadores, destaque no slide e arquivo de alertas.
+	MetricNames   map[string]string
+	AlertSlide    string
+	AlertItem     string // indicador, valor, meta
+	AlertsTitle   string
+	AlertsTargets string
+	AlertsNone    string
+	AlertsColumns [5]string
+}
+
+// compareMessages: nota dos testes de --compare (ver compare.go).
+type compareMessages struct {
+	Vs             string // período base, % base, % atual, diferença p.p., p, (não) significativo
+	Significant    string
+	NotSignificant string
+	Floors         string // alfa, nº de andares testados, lista "andar (p ajustado)"
+	FloorsNone     string // alfa, nº de andares testados
+	NoData         string // período base
+}
+
+// heatmapMessages: título e legenda do mapa de calor (ver heatmap.go).
+type heatmapMessages struct {
+	Title   string // indicadores do mapa ("% Excelente / % Sim")
+	Overall string // primeira coluna, o hospital inteiro
+	Legend  string // faixas (Range) separadas por "; "
+	Range   string // indicador, % até onde é vermelho, % a partir do qual é verde
+	LowN    string // --min-n
+}
+
+// driversMessages: slide dos fatores da recomendação (ver drivers.go).
+type driversMessages struct {
+	Title     string
+	XAxis     string
+	YAxis     string
+	Note      string
+	LowN      string            // --min-n
+	Quadrants map[string]string // quadPriority, quadKeep, quadWatch, quadSecondary
+	Columns   [5]string
+}
+
+// introMessages: textos dos slides de abertura (ver pptx_intro.go).
+type introMessages struct {
+	MethodologyTitle string
+	SummaryTitle     string
+	Sample           string // respostas, andares
+	Collection       string // período
+	DedupeRule       string // segundos, removidas
+	DedupeStrict     string // removidas (--dedupe-sec=0)
+	DedupeOff        string
+	Excluded         string // lista de perguntas
+	Responses        string // respostas, removidas
+	Best             string // pergunta, %
+	Worst            string // pergunta, %
+	Recommend        string // %
+	Alerts           string // metas não atingidas
+	PositiveNote     string
+}
+
+const defaultLang = "pt-BR"
+
+var catalogs = map[string]*messages{
+	"pt-BR": {
+		Header: []string{
+			"ANDAR",
+			"Paciente",
+			"ATENDIMENTO DE RECEPÇÃO/ORIENTAÇÃO",
+			"ATENDIMENTO MÉDICO",
+			"ATENDIMENTO DE ENFERMAGEM",
+			"ATENDIMENTO REGULAÇÃO",
+			"ATENDIMENTO EQUIPE MULTI(PSICOLOGIA / SERVIÇO SOCIAL / NUTRIÇÃO)",
+			"ATENDIMENTO DE EXAMES DIAGNÓSTICOS",
+			"ATENDIMENTO TELEFÔNICO",
+			"LIMPEZA DA UNIDADE",
+			"INSTALAÇÕES",
+			"TEMPO DE ESPERA DO ATENDIMENTO",
+			"Recomendaria esse hospital para seus amigos e familiares?",
+			"Teve confirmado em algum momento do seu atendimento seu nome e data de nascimento?",
+			"Recebeu informações sobre a continuidade de seu tratamento?",
+			"Foi adequadamente orientado quanto a forma de utilização de suas medicações?",
+			"SEU PROBLEMA DE SAÚDE FOI RESOLVIDO OU CONTROLADO NO HOSPITAL DIA ?",
+			"CASO NÃO, EXPLIQUE O PORQUÊ :",
+			"SE ALIMENTA AO MÍNIMO COM 5 PORÇÕES DE FRUTAS, VERDURAS E LEGUMES DIARIAMENTE?",
+			"Você foi atendido com gentileza e empatia? Sentiu nossos colaboradores motivados?",
+			"Tempo de acesso e de retorno na especialidade",
+			"O QUE IMPORTA PARA VOCÊ EM NOSSO SERVIÇO:",
+			"Data - Criação",
+			"Cadastrador",
+		},
+		Unit: "UNIDADE",
+		Answers: map[string]string{
+			"1": "Ruim",
+			"2": "Boa",
+			"3": "Regular",
+			"4": "Excelente",
+			"5": "Não utilizei",
+			"6": "Sim",
+			"7": "Não",
+		},
+		Months: [12]string{
+			"Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho",
+			"Julho", "Agosto", "Setembro", "Outubro", "Novembro", "Dezembro",
+		},
+		ReportTitle:      "Relatório %s/%04d",
+		ReportRangeTitle: "Relatório %s/%04d a %s/%04d",
+		ReportDaysTitle:  "Relatório %s a %s",
+		DateLayout:       "02/01/2006",
+		PeriodRange:      "%s a %s",
+		ChartN:           "n = %d (IC 95%% entre colchetes)",
+		ChartLowN:        "n = %d - amostra pequena (mínimo %d), interpretar com cautela",
+		Intro: introMessages{
+			MethodologyTitle: "Metodologia",
+			SummaryTitle:     "Resumo executivo",
+			Sample:           "Amostra: %d respostas de %d andares",
+			Collection:       "Coleta: questionário de experiência do paciente registrado no sistema (%s)",
+			DedupeRule:       "Duplicidades: respostas seguidas do mesmo paciente com até %d s de diferença contam uma vez (%d removidas)",
+			DedupeStrict:     "Duplicidades: respostas seguidas do mesmo paciente com o mesmo horário contam uma vez (%d removidas)",
+			DedupeOff:        "Duplicidades: não removidas",
+			Excluded:         "Fora dos gráficos (resposta aberta): %s",
+			Responses:        "%d respostas no período (%d duplicadas removidas)",
+			Best:             "Melhor avaliação: %s (%.0f%% positivas)",
+			Worst:            "Pior avaliação: %s (%.0f%% positivas)",
+			Recommend:        "Recomendariam o hospital: %.0f%%",
+			Alerts:           "Metas não atingidas: %d (destacadas nos slides)",
+			PositiveNote:     "Positivas = Excelente/Boa ou Sim; \"Não utilizei\" fica fora da base.",
+		},
+		Compare: compareMessages{
+			Vs:             "vs %s: %.0f%% → %.0f%% positivas (%+.1f p.p.), p = %.3f - %s",
+			Significant:    "diferença significativa",
+			NotSignificant: "diferença não significativa",
+			Floors:         "Andares que diferem do restante (α = %g, p ajustado por Holm entre %d andares): %s",
+			FloorsNone:     "Nenhum andar difere do restante (α = %g, p ajustado por Holm entre %d andares)",
+			NoData:         "vs %s: sem respostas para comparar",
+		},
+		Heatmap: heatmapMessages{
+			Title:   "Mapa de calor: %s por pergunta e andar",
+			Overall: "Geral",
+			Legend:  "Faixas fixas: %s.",
+			Range:   "%% %s vermelho ≤ %.0f%%, verde ≥ %.0f%%",
+			LowN:    "Cinza: n < %d, interpretar com cautela.",
+		},
+		Drivers: driversMessages{
+			Title: "O que leva a recomendar o hospital",
+			XAxis: "Desempenho (% Excelente)",
+			YAxis: "Importância (correlação com recomendar)",
+			Note:  "Importância: correlação entre a nota de cada pergunta e responder Sim à pergunta 11, só com quem respondeu as duas. Linhas tracejadas: médias. Correlação não prova causa.",
+			LowN:  "* n < %d, interpretar com cautela.",
+			Quadrants: map[string]string{
+				quadPriority: "Prioridade", quadKeep: "Manter", quadWatch: "Acompanhar", quadSecondary: "Secundário",
+			},
+			Columns: [5]string{"#", "Pergunta", "r", "% Excelente", "Quadrante"},
+		},
+		MetricNames: map[string]string{
+			metricTopBox: "Excelente", metricPositive: "positivas", metricYes: "Sim", metricBottom: "Ruim",
+		},
+		AlertSlide:    "Meta não atingida:",
+		AlertItem:     "%s %.1f%% (meta %s%%)",
+		AlertsTitle:   "Alertas de metas",
+		AlertsTargets: "Metas",
+		AlertsNone:    "Todas as metas foram atingidas.",
+		AlertsColumns: [5]string{"Pergunta", "Indicador", "Meta", "Valor", "n"},
+	},
+	"en": {
+		Header: []string{
+			"FLOOR",
+			"Patient",
+			"RECEPTION/GUIDANCE SERVICE",
+			"MEDICAL CARE",
+			"NURSING CARE",
+			"PATIENT FLOW/REGULATION SERVICE",
+			"MULTIDISCIPLINARY TEAM (PSYCHOLOGY / SOCIAL WORK / NUTRITION)",
+			"DIAGNOSTIC EXAMS SERVICE",
+			"TELEPHONE SERVICE",
+			"UNIT CLEANLINESS",
+			"FACILITIES",
+			"WAITING TIME",
+			"Would you recommend this hospital to your friends and family?",
+			"Were your name and date of birth confirmed at some point during your care?",
+			"Did you receive information about the continuity of your treatment?",
+			"Were you properly instructed on how to use your medications?",
+			"WAS YOUR HEALTH PROBLEM RESOLVED OR CONTROLLED AT THE DAY HOSPITAL?",
+			"IF NOT, EXPLAIN WHY:",
+			"DO YOU EAT AT LEAST 5 PORTIONS OF FRUIT AND VEGETABLES DAILY?",
+			"Were you treated with kindness and empathy? Did our staff seem motivated?",
+			"Time to access and return to the specialty",
+			"WHAT MATTERS TO YOU IN OUR SERVICE:",
+			"Created at",
+			"Registered by",
+		},
+		Unit: "UNIT",
+		Answers: map[string]string{
+			"1": "Poor",
+			"2": "Good",
+			"3": "Fair",
+			"4": "Excellent",
+			"5": "Did not use",
+			"6": "Yes",
+			"7": "No",
+		},
+		Months: [12]string{
+			"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December",
+		},
+		ReportTitle:      "Report %s %04d",
+		ReportRangeTitle: "Report %s %04d to %s %04d",
+		ReportDaysTitle:  "Report %s to %s",
+		DateLayout:       "2006-01-02",
+		PeriodRange:      "%s to %s",
+		ChartN:           "n = %d (95%% CI in brackets)",
+		ChartLowN:        "n = %d - small sample (minimum %d), interpret with caution",
+		Intro: introMessages{
+			MethodologyTitle: "Methodology",
+			SummaryTitle:     "Executive summary",
+			Sample:           "Sample: %d responses from %d floors",
+			Collection:       "Collection: patient experience questionnaire recorded in the hospital system (%s)",
+			DedupeRule:       "Duplicates: consecutive responses from the same patient within %d s count once (%d removed)",
+			DedupeStrict:     "Duplicates: consecutive responses from the same patient with the same timestamp count once (%d removed)",
+			DedupeOff:        "Duplicates: not removed",
+			Excluded:         "Not charted (open answer): %s",
+			Responses:        "%d responses in the period (%d duplicates removed)",
+			Best:             "Best rated: %s (%.0f%% positive)",
+			Worst:            "Lowest rated: %s (%.0f%% positive)",
+			Recommend:        "Would recommend the hospital: %.0f%%",
+			Alerts:           "Targets missed: %d (highlighted on the slides)",
+			PositiveNote:     "Positive = Excellent/Good or Yes; \"Did not use\" is left out of the base.",
+		},
+		Compare: compareMessages{
+			Vs:             "vs %s: %.0f%% → %.0f%% positive (%+.1f pp), p = %.3f - %s",
+			Significant:    "significant difference",
+			NotSignificant: "not significant",
+			Floors:         "Floors that differ from the rest (α = %g, p Holm-adjusted across %d floors): %s",
+			FloorsNone:     "No floor differs from the rest (α = %g, p Holm-adjusted across %d floors)",
+			NoData:         "vs %s: no responses to compare",
+		},
+		Heatmap: heatmapMessages{
+			Title:   "Heatmap: %s by question and floor",
+			Overall: "Overall",
+			Legend:  "Fixed bands: %s.",
+			Range:   "%% %s red ≤ %.0f%%, green ≥ %.0f%%",
+			LowN:    "Grey: n < %d, interpret with caution.",
+		},
+		Drivers: driversMessages{
+			Title: "What drives recommending the hospital",
+			XAxis: "Performance (% Excellent)",
+			YAxis: "Importance (correlation with recommending)",
+			Note:  "Importance: correlation between each question's rating and answering Yes to question 11, among those who answered both. Dashed lines: averages. Correlation does not prove causation.",
+			LowN:  "* n < %d, interpret with caution.",
+			Quadrants: map[string]string{
+				quadPriority: "Priority", quadKeep: "Maintain", quadWatch: "Monitor", quadSecondary: "Secondary",
+			},
+			Columns: [5]string{"#", "Question", "r", "% Excellent", "Quadrant"},
+		},
+		MetricNames: map[string]string{
+			metricTopBox: "Excellent", metricPositive: "positive", metricYes: "Yes", metricBottom: "Poor",
+		},
+		AlertSlide:    "Target missed:",
+		AlertItem:     "%s %.1f%% (target %s%%)",
+		AlertsTitle:   "Target alerts",
+		AlertsTargets: "Targets",
+		AlertsNone:    "All targets were met.",
+		AlertsColumns: [5]string{"Question", "Indicator", "Target", "Value", "n"},
+	},
+	"es": {
+		Header: []string{
+			"PISO",
+			"Paciente",
+			"ATENCIÓN DE RECEPCIÓN/ORIENTACIÓN",
+			"ATENCIÓN MÉDICA",
+			"ATENCIÓN DE ENFERMERÍA",
+			"ATENCIÓN DE REGULACIÓN",
+			"ATENCIÓN EQUIPO MULTI (PSICOLOGÍA / SERVICIO SOCIAL / NUTRICIÓN)",
+			"ATENCIÓN DE EXÁMENES DIAGNÓSTICOS",
+			"ATENCIÓN TELEFÓNICA",
+			"LIMPIEZA DE LA UNIDAD",
+			"INSTALACIONES",
+			"TIEMPO DE ESPERA DE LA ATENCIÓN",
+			"¿Recomendaría este hospital a sus amigos y familiares?",
+			"¿Se confirmó en algún momento de su atención su nombre y fecha de nacimiento?",
+			"¿Recibió información sobre la continuidad de su tratamiento?",
+			"¿Fue orientado adecuadamente sobre la forma de uso de sus medicamentos?",
+			"¿SU PROBLEMA DE SALUD FUE RESUELTO O CONTROLADO EN EL HOSPITAL DE DÍA?",
+			"EN CASO NEGATIVO, EXPLIQUE POR QUÉ:",
+			"¿CONSUME AL MENOS 5 PORCIONES DE FRUTAS, VERDURAS Y HORTALIZAS AL DÍA?",
+			"¿Fue atendido con amabilidad y empatía? ¿Sintió a nuestros colaboradores motivados?",
+			"Tiempo de acceso y de retorno en la especialidad",
+			"LO QUE IMPORTA PARA USTED EN NUESTRO SERVICIO:",
+			"Fecha - Creación",
+			"Registrado por",
+		},
+		Unit: "UNIDAD",
+		Answers: map[string]string{
+			"1": "Mala",
+			"2": "Buena",
+			"3": "Regular",
+			"4": "Excelente",
+			"5": "No utilicé",
+			"6": "Sí",
+			"7": "No",
+		},
+		Months: [12]string{
+			"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio",
+			"Julio", "Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre",
+		},
+		ReportTitle:      "Informe %s/%04d",
+		ReportRangeTitle: "Informe %s/%04d a %s/%04d",
+		ReportDaysTitle:  "Informe %s a %s",
+		DateLayout:       "02/01/2006",
+		PeriodRange:      "%s a %s",
+		ChartN:           "n = %d (IC 95%% entre corchetes)",
+		ChartLowN:        "n = %d - muestra pequeña (mínimo %d), interpretar con cautela",
+		Intro: introMessages{
+			MethodologyTitle: "Metodología",
+			SummaryTitle:     "Resumen ejecutivo",
+			Sample:           "Muestra: %d respuestas de %d pisos",
+			Collection:       "Recolección: cuestionario de experiencia del paciente registrado en el sistema (%s)",
+			DedupeRule:       "Duplicados: respuestas seguidas del mismo paciente con hasta %d s de diferencia cuentan una vez (%d eliminadas)",
+			DedupeStrict:     "Duplicados: respuestas seguidas del mismo paciente con la misma hora cuentan una vez (%d eliminadas)",
+			DedupeOff:        "Duplicados: no eliminados",
+			Excluded:         "Fuera de los gráficos (respuesta abierta): %s",
+			Responses:        "%d respuestas en el período (%d duplicadas eliminadas)",
+			Best:             "Mejor evaluación: %s (%.0f%% positivas)",
+			Worst:            "Peor evaluación: %s (%.0f%% positivas)",
+			Recommend:        "Recomendarían el hospital: %.0f%%",
+			Alerts:           "Metas no alcanzadas: %d (destacadas en las diapositivas)",
+			PositiveNote:     "Positivas = Excelente/Buena o Sí; \"No utilicé\" queda fuera de la base.",
+		},
+		Compare: compareMessages{
+			Vs:             "vs %s: %.0f%% → %.0f%% positivas (%+.1f p.p.), p = %.3f - %s",
+			Significant:    "diferencia significativa",
+			NotSignificant: "diferencia no significativa",
+			Floors:         "Pisos que difieren del resto (α = %g, p ajustado por Holm entre %d pisos): %s",
+			FloorsNone:     "Ningún piso difiere del resto (α = %g, p ajustado por Holm entre %d pisos)",
+			NoData:         "vs %s: sin respuestas para comparar",
+		},
+		Heatmap: heatmapMessages{
+			Title:   "Mapa de calor: %s por pregunta y piso",
+			Overall: "General",
+			Legend:  "Franjas fijas: %s.",
+			Range:   "%% %s rojo ≤ %.0f%%, verde ≥ %.0f%%",
+			LowN:    "Gris: n < %d, interpretar con cautela.",
+		},
+		Drivers: driversMessages{
+			Title: "Qué lleva a recomendar el hospital",
+			XAxis: "Desempeño (% Excelente)",
+			YAxis: "Importancia (correlación con recomendar)",
+			Note:  "Importancia: correlación entre la nota de cada pregunta y responder Sí a la pregunta 11, solo con quienes respondieron ambas. Líneas discontinuas: promedios. Correlación no prueba causa.",
+			LowN:  "* n < %d, interpretar con cautela.",
+			Quadrants: map[string]string{
+				quadPriority: "Prioridad", quadKeep: "Mantener", quadWatch: "Acompañar", quadSecondary: "Secundario",
+			},
+			Columns: [5]string{"#", "Pregunta", "r", "% Excelente", "Cuadrante"},
+		},
+		MetricNames: map[string]string{
+			metricTopBox: "Excelente", metricPositive: "positivas", metricYes: "Sí", metricBottom: "Mala",
+		},
+		AlertSlide:    "Meta no alcanzada:",
+		AlertItem:     "%s %.1f%% (meta %s%%)",
+		AlertsTitle:   "Alertas de metas",
+		AlertsTargets: "Metas",
+		AlertsNone:    "Se alcanzaron todas las metas.",
+		AlertsColumns: [5]string{"Pregunta", "Indicador", "Meta", "Valor", "n"},
+	},
+}
+
+// msgs é o catálogo ativo (escolhido por --lang em main).
+var msgs = catalogs[defaultLang]
+
+func setLanguage(lang string) error {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		lang = defaultLang
+	}
+	for k, m := range catalogs {
+		// Aceita "pt-br", "pt_BR", "EN"...
+		if strings.EqualFold(strings.ReplaceAll(lang, "_", "-"), k) {
+			msgs = m
+			return nil
+		}
+	}
+	// "pt" sozinho também vale.
+	if strings.EqualFold(lang, "pt") {
+		msgs = catalogs[defaultLang]
+		return nil
+	}
+	return fmt.Errorf("unsupported --lang %q (use one of: %s)", lang, strings.Join(supportedLanguages(), ", "))
+}
+
+func supportedLanguages() []string {
+	out := make([]string, 0, len(catalogs))
+	for k := range catalogs {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (m *messages) monthName(t time.Time) string {
+	return m.Months[int(t.Month())-1]
+}
+
+func (m *messages) reportTitle(periodStart time.Time) string {
+	return fmt.Sprintf(m.ReportTitle, m.monthName(periodStart), periodStart.Year())
+}
+
+// periodTitle usa o título de um mês, o de intervalo de meses ou, se o
+// período não cobre meses inteiros, o de datas. last é inclusivo.
+func (m *messages) periodTitle(first, last time.Time) string {
+	if !monthAligned(first, last) {
+		return fmt.Sprintf(m.ReportDaysTitle, first.Format(m.DateLayout), last.Format(m.DateLayout))
+	}
+	if sameMonth(first, last) {
+		return m.reportTitle(first)
+	}
+	return fmt.Sprintf(m.ReportRangeTitle, m.monthName(first), first.Year(), m.monthName(last), last.Year())
+}
+
+// periodRange: "01/12/2025 a 31/12/2025" (last inclusivo).
+func (m *messages) periodRange(first, last time.Time) string {
+	return fmt.Sprintf(m.PeriodRange, first.Format(m.DateLayout), last.Format(m.DateLayout))
+}
+
+// periodShort: "Dezembro/2025" para um mês, senão o intervalo de datas.
+func (m *messages) periodShort(first, last time.Time) string {
+	if monthAligned(first, last) && sameMonth(first, last) {
+		return fmt.Sprintf("%s/%04d", m.monthName(first), first.Year())
+	}
+	return m.periodRange(first, last)
+}
+
+// shortMonth: "Dez/2025" a partir da chave "2025-12".
+func (m *messages) shortMonth(key string) string {
+	t, err := time.Parse("2006-01", key)
+	if err != nil {
+		return key
+	}
+	name := []rune(m.monthName(t))
+	if len(name) > 3 {
+		name = name[:3]
+	}
+	return fmt.Sprintf("%s/%04d", string(name), t.Year())
+}
+
+// metricName: nome do indicador de --targets no idioma ativo.
+func (m *messages) metricName(metric string) string {
+	if name, ok := m.MetricNames[metric]; ok {
+		return name
+	}
+	return metric
+}
+
+// answerCode devolve o código (1..7) de um rótulo de resposta em qualquer
+// idioma conhecido. Serve para reaproveitar um CSV gerado em outro idioma.
+func answerCode(label string) (string, bool) {
+	for _, m := range catalogs {
+		for code, l := range m.Answers {
+			if strings.EqualFold(l, label) {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
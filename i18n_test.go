@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetLanguage(t *testing.T) {
+	defer func(prev *messages) { msgs = prev }(msgs)
+	for _, lang := range []string{"", "pt", "pt_BR", "PT-br", "en", " es "} {
+		if err := setLanguage(lang); err != nil {
+			t.Errorf("setLanguage(%q): %v", lang, err)
+		}
+	}
+	if err := setLanguage("EN"); err != nil || msgs != catalogs["en"] {
+		t.Errorf("EN: msgs not switched (%v)", err)
+	}
+	for _, lang := range []string{"fr", "pt-PT", "english"} {
+		err := setLanguage(lang)
+		if err == nil || !strings.Contains(err.Error(), "unsupported --lang") {
+			t.Errorf("setLanguage(%q) = %v, want unsupported", lang, err)
+		}
+	}
+	// Idioma desconhecido não troca o catálogo ativo.
+	if msgs != catalogs["en"] {
+		t.Error("failed setLanguage changed msgs")
+	}
+}
+
+func TestAnswerCode(t *testing.T) {
+	tests := map[string]string{
+		// pt-BR
+		"Ruim": "1", "Boa": "2", "Regular": "3", "Excelente": "4", "Não utilizei": "5", "Sim": "6", "Não": "7",
+		// en
+		"Poor": "1", "Good": "2", "Fair": "3", "Excellent": "4", "Did not use": "5", "Yes": "6", "No": "7",
+		// es
+		"Mala": "1", "Buena": "2", "No utilicé": "5", "Sí": "6",
+		// maiúsculas/minúsculas não importam
+		"EXCELENTE": "4", "yes": "6",
+	}
+	for label, want := range tests {
+		if got, ok := answerCode(label); !ok || got != want {
+			t.Errorf("answerCode(%q) = %q, %v; want %q", label, got, ok, want)
+		}
+	}
+	for _, label := range []string{"", "talvez", "4"} {
+		if code, ok := answerCode(label); ok {
+			t.Errorf("answerCode(%q) = %q, want not found", label, code)
+		}
+	}
+}
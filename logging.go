@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Logs estruturados (slog) no stderr, em texto ou JSON (--log-format), com o
+// run_id da execução em todas as linhas para achar no agregador tudo o que
+// saiu da mesma rodada. As linhas "OK: ..." continuam no stdout: são o
+// resultado para quem roda à mão, não log.
+
+// runID identifica a execução nos logs e no webhook.
+var runID = newRunID()
+
+func newRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "000000000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// setupLogger troca o logger padrão (também o do pacote log) pelo do --log-format.
+func setupLogger(format, level string) error {
+	lvl, ok := logLevels[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return fmt.Errorf("invalid --log-level %q (use debug, info, warn or error)", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid --log-format %q (use text or json)", format)
+	}
+	slog.SetDefault(slog.New(h).With("run_id", runID))
+	return nil
+}
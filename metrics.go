@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Métricas Prometheus da execução com banco, para o Grafana do hospital
+// alertar tanto na saúde do job quanto em queda de satisfação:
+//   - --metrics-file grava no formato texto do node_exporter (textfile
+//     collector) ao terminar, com sucesso ou não;
+//   - --metrics-addr serve /metrics enquanto a execução roda (útil no
+//     backfill, que pode levar minutos).
+//
+// O formato é escrito à mão (text exposition 0.0.4); são poucas séries e não
+// vale trazer o client_golang. Tudo é gauge: cada execução é um processo novo
+// e recomeça do zero, então um counter voltaria a 0 a cada mês e rate()/increase()
+// leriam isso como reset. Erros e novas tentativas são "da última execução"
+// (*_last_run).
+
+const metricsPrefix = "auto_relatorio_"
+
+// metricFamilies: HELP/TYPE de cada métrica, na ordem de saída.
+var metricFamilies = []struct{ Name, Type, Help string }{
+	{"run_duration_seconds", "gauge", "Duration of the last run."},
+	{"last_run_timestamp_seconds", "gauge", "Unix time when the last run ended."},
+	{"last_run_success", "gauge", "1 if the last run finished without errors."},
+	{"errors_last_run", "gauge", "Errors in the last run, by stage."},
+	{"retries_last_run", "gauge", "Transient database errors retried in the last run, by operation."},
+	{"query_duration_seconds", "gauge", "Time until the database started returning rows, by period."},
+	{"rows_exported", "gauge", "Rows written to the CSV, by period."},
+	{"duplicates_skipped", "gauge", "Consecutive duplicate rows removed, by period."},
+	{"alerts", "gauge", "Missed --targets, by period."},
+	{"responses", "gauge", "Answers per question (without \"Não utilizei\"), by period and floor."},
+	{"topbox_percent", "gauge", "Share of Excelente among rating answers, by period, floor and question."},
+}
+
+type runMetrics struct {
+	mu      sync.Mutex
+	began   time.Time
+	file    string
+	samples map[string]map[string]float64 // métrica -> labels -> valor
+}
+
+// metrics é configurado em main; nil = sem métricas.
+var metrics *runMetrics
+
+func newRunMetrics(file, addr string) (*runMetrics, error) {
+	file, addr = strings.TrimSpace(file), strings.TrimSpace(addr)
+	if file == "" && addr == "" {
+		return nil, nil
+	}
+	if file != "" && filepath.Ext(file) != ".prom" {
+		return nil, fmt.Errorf("--metrics-file must end in .prom (node_exporter textfile collector): %s", file)
+	}
+	m := &runMetrics{began: time.Now(), file: file, samples: map[string]map[string]float64{}}
+	for _, st := range stages {
+		m.set("errors_last_run", 0, "stage", st)
+	}
+	for _, op := range []string{"ping", "export"} {
+		m.set("retries_last_run", 0, "op", op)
+	}
+	if addr != "" {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("--metrics-addr: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			_, _ = w.Write([]byte(m.render()))
+		})
+		go func() { _ = http.Serve(ln, mux) }()
+		slog.Info("métricas em /metrics", "url", "http://"+ln.Addr().String()+"/metrics")
+	}
+	return m, nil
+}
+
+func (m *runMetrics) set(name string, v float64, labels ...string) {
+	key := formatLabels(labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.samples[name] == nil {
+		m.samples[name] = map[string]float64{}
+	}
+	m.samples[name][key] = v
+}
+
+func (m *runMetrics) add(name string, v float64, labels ...string) {
+	key := formatLabels(labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.samples[name] == nil {
+		m.samples[name] = map[string]float64{}
+	}
+	m.samples[name][key] += v
+}
+
+// observeExport registra linhas, duplicadas e tempo da query de um período.
+func (m *runMetrics) observeExport(period string, res exportResult) {
+	if m == nil {
+		return
+	}
+	m.set("query_duration_seconds", res.Query.Seconds(), "period", period)
+	m.set("rows_exported", float64(res.Rows), "period", period)
+	m.set("duplicates_skipped", float64(res.Skipped), "period", period)
+}
+
+// observeScores registra o top-box de cada pergunta de escala nas respostas
+// do período, no geral (floor="all") e por andar.
+func (m *runMetrics) observeScores(period string, in *periodAnswers) {
+	if m == nil {
+		return
+	}
+	merged, err := in.load()
+	if err != nil {
+		slog.Warn("top-box não calculado para as métricas", "err", err)
+		return
+	}
+	ac := merged.Counts
+	add := func(floor string, counts []map[string]int) {
+		for i, qc := range ac.Questions {
+			pct, n, ok := metricValue(metricTopBox, counts[i])
+			if !ok {
+				continue
+			}
+			q := strconv.Itoa(qc.Number)
+			m.set("topbox_percent", pct, "period", period, "floor", floor, "question", q)
+			m.set("responses", float64(n), "period", period, "floor", floor, "question", q)
+		}
+	}
+	add("all", ac.Total)
+	for floor, counts := range ac.ByFloor {
+		if floor != "" {
+			add(floor, counts)
+		}
+	}
+}
+
+func (m *runMetrics) observeAlerts(period string, n int) {
+	if m == nil {
+		return
+	}
+	m.set("alerts", float64(n), "period", period)
+}
+
+func (m *runMetrics) observeRetry(op string) {
+	if m == nil {
+		return
+	}
+	m.add("retries_last_run", 1, "op", op)
+}
+
+// countError conta a falha na sua etapa; no backfill e no --units, cada mês
+// ou unidade que falhou.
+func (m *runMetrics) countError(err error) {
+	if m == nil {
+		return
+	}
+	errs := []error{err}
+	var be *batchError
+	if errors.As(err, &be) {
+		errs = be.Errs
+	}
+	for _, e := range errs {
+		m.add("errors_last_run", 1, "stage", errorStage(e, "unknown"))
+	}
+}
+
+// finish fecha a execução e grava o --metrics-file. Sem sucesso, vale o
+// errors_last_run para saber em que etapa parou.
+func (m *runMetrics) finish(success bool) {
+	if m == nil {
+		return
+	}
+	ok := 0.0
+	if success {
+		ok = 1
+	}
+	m.set("run_duration_seconds", time.Since(m.began).Seconds())
+	m.set("last_run_timestamp_seconds", float64(time.Now().Unix()))
+	m.set("last_run_success", ok)
+	if m.file == "" {
+		return
+	}
+	if err := writeFileAtomic(m.file, []byte(m.render())); err != nil {
+		slog.Warn("métricas não gravadas", "path", m.file, "err", err)
+	}
+}
+
+func (m *runMetrics) render() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sb strings.Builder
+	for _, f := range metricFamilies {
+		series := m.samples[f.Name]
+		if len(series) == 0 {
+			continue
+		}
+		name := metricsPrefix + f.Name
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, f.Help, name, f.Type)
+		keys := make([]string, 0, len(series))
+		for k := range series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "%s%s %s\n", name, k, strconv.FormatFloat(series[k], 'f', -1, 64))
+		}
+	}
+	return sb.String()
+}
+
+// formatLabels monta {a="x",b="y"} a partir de pares nome, valor.
+func formatLabels(kv []string) string {
+	if len(kv) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+`="`+escapeLabel(kv[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+// writeFileAtomic grava num temporário e renomeia, para o node_exporter
+// nunca ler um arquivo pela metade.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".metrics-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunMetricsLastRunGauges(t *testing.T) {
+	m, err := newRunMetrics("", "")
+	if err != nil || m != nil {
+		t.Fatalf("no flags: m=%v err=%v", m, err)
+	}
+	m = &runMetrics{samples: map[string]map[string]float64{}}
+	m.set("errors_last_run", 0, "stage", stageDB)
+	m.set("retries_last_run", 0, "op", "ping")
+	m.observeRetry("ping")
+	m.observeRetry("ping")
+	m.countError(withStage(stageDB, errors.New("boom")))
+
+	out := m.render()
+	for _, want := range []string{
+		"# TYPE auto_relatorio_errors_last_run gauge\n",
+		"# TYPE auto_relatorio_retries_last_run gauge\n",
+		`auto_relatorio_errors_last_run{stage="db"} 1` + "\n",
+		`auto_relatorio_retries_last_run{op="ping"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "counter") || strings.Contains(out, "_total") {
+		t.Errorf("per-run values must not be exposed as counters:\n%s", out)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Aviso por webhook (--webhook) ao fim de cada execução com banco: período,
+// linhas, duplicadas removidas, principais KPIs e arquivos gerados, ou o erro
+// que derrubou a execução. --webhook-format escolhe o corpo do POST:
+//   - generic: o runSummary em JSON, para automações próprias;
+//   - teams: MessageCard de Incoming Webhook do Microsoft Teams;
+//   - slack: {"text": ...} de Incoming Webhook do Slack (também serve ao Mattermost).
+//
+// Falha ao avisar não muda o resultado da execução; vira só um aviso no log.
+// A URL do webhook é segredo (o token vai no caminho ou na query), então erros
+// e logs só mostram esquema e host (redactURL).
+
+// webhookTimeout é var para o teste não esperar 10 s.
+var webhookTimeout = 10 * time.Second
+
+var webhookFormats = []string{"generic", "teams", "slack"}
+
+type webhook struct {
+	URL    string
+	Format string
+	period string // período do aviso de falha, quando já se sabe
+	began  time.Time
+}
+
+// notifier é configurado em main; nil = sem webhook.
+var notifier *webhook
+
+func newWebhook(rawURL, format string) (*webhook, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		rawURL = strings.TrimSpace(os.Getenv("WEBHOOK_URL"))
+	}
+	if rawURL == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		return nil, fmt.Errorf("--webhook must be an http(s) URL: %s", redactURL(rawURL))
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	for _, f := range webhookFormats {
+		if f == format {
+			return &webhook{URL: rawURL, Format: format, began: time.Now()}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid --webhook-format %q (use %s)", format, strings.Join(webhookFormats, ", "))
+}
+
+// runSummary é o corpo do formato generic.
+type runSummary struct {
+	RunID    string       `json:"run_id"`
+	Status   string       `json:"status"` // ok, alerta ou erro
+	Period   string       `json:"periodo,omitempty"`
+	Start    string       `json:"inicio,omitempty"`
+	End      string       `json:"fim,omitempty"`
+	Rows     int          `json:"linhas"`
+	Skipped  int          `json:"duplicadas_removidas"`
+	KPIs     []summaryKPI `json:"kpis,omitempty"`
+	Alerts   int          `json:"alertas"`
+	Paths    []string     `json:"arquivos,omitempty"`
+	Error    string       `json:"erro,omitempty"`
+	Host     string       `json:"host,omitempty"`
+	Duration float64      `json:"duracao_s"`
+}
+
+type summaryKPI struct {
+	Name     string  `json:"nome"` // recomendacao, melhor ou pior
+	Question int     `json:"questao"`
+	Title    string  `json:"titulo"`
+	Positive float64 `json:"positivas_pct"` // 1 casa decimal
+	Text     string  `json:"texto"`         // a mesma frase do resumo executivo
+}
+
+// summaryKPIs: recomendação, melhor e pior pergunta, como no resumo executivo.
+func summaryKPIs(ac *answerCounts) []summaryKPI {
+	im := msgs.Intro
+	h := summarize(ac)
+	var out []summaryKPI
+	if h.HasRecommend {
+		out = append(out, summaryKPI{Name: "recomendacao", Question: recommendQuestion, Positive: h.Recommend, Text: fmt.Sprintf(im.Recommend, h.Recommend)})
+		for _, qc := range ac.Questions {
+			if qc.Number == recommendQuestion {
+				out[0].Title = qc.Title
+			}
+		}
+	}
+	if h.Best >= 0 {
+		qc := ac.Questions[h.Best]
+		out = append(out, summaryKPI{Name: "melhor", Question: qc.Number, Title: qc.Title, Positive: h.BestPct, Text: fmt.Sprintf(im.Best, qc.Title, h.BestPct)})
+	}
+	if h.Worst >= 0 && h.Worst != h.Best {
+		qc := ac.Questions[h.Worst]
+		out = append(out, summaryKPI{Name: "pior", Question: qc.Number, Title: qc.Title, Positive: h.WorstPct, Text: fmt.Sprintf(im.Worst, qc.Title, h.WorstPct)})
+	}
+	for i := range out {
+		out[i].Positive = math.Round(out[i].Positive*10) / 10
+	}
+	return out
+}
+
+// csvKPIs devolve os KPIs do aviso; sem CSV (ou ilegível), nenhum.
+func csvKPIs(in *periodAnswers) []summaryKPI {
+	if in == nil {
+		return nil
+	}
+	merged, err := in.load()
+	if err != nil {
+		return nil
+	}
+	return summaryKPIs(merged.Counts)
+}
+
+// notify envia o resumo; erros só vão para o log.
+func (w *webhook) notify(s runSummary) {
+	if w == nil {
+		return
+	}
+	s.RunID = runID
+	if s.Period == "" {
+		s.Period = w.period
+	}
+	if s.Host == "" {
+		s.Host, _ = os.Hostname()
+	}
+	s.Duration = time.Since(w.began).Round(time.Millisecond).Seconds()
+	body, err := w.payload(s)
+	if err != nil {
+		slog.Warn("webhook não enviado", "err", err)
+		return
+	}
+	if err := w.post(body); err != nil {
+		slog.Warn("webhook não enviado", "err", err)
+	}
+}
+
+func (w *webhook) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	target := redactURL(w.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("POST %s: %w", target, stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "auto_relatorio")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("POST %s: %w", target, stripURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s %s", target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redactURL deixa só esquema e host da URL ("https://hooks.slack.com/...").
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		if i := strings.Index(raw, "://"); i > 0 {
+			return raw[:i] + "://..."
+		}
+		return "..."
+	}
+	s := u.Scheme + "://" + u.Host
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		s += "/..."
+	}
+	return s
+}
+
+// stripURL tira a URL completa que o net/http põe no *url.Error.
+func stripURL(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Err
+	}
+	return err
+}
+
+func (w *webhook) payload(s runSummary) ([]byte, error) {
+	switch w.Format {
+	case "teams":
+		return json.Marshal(teamsCard(s))
+	case "slack":
+		return json.Marshal(map[string]string{"text": slackText(s)})
+	}
+	return json.Marshal(s)
+}
+
+// summaryHeadline: primeira linha do aviso no Teams/Slack.
+func summaryHeadline(s runSummary) string {
+	switch s.Status {
+	case "erro":
+		return "Relatório de satisfação FALHOU " + s.Period
+	case "alerta":
+		return fmt.Sprintf("Relatório de satisfação %s: %d metas não atingidas", s.Period, s.Alerts)
+	}
+	return "Relatório de satisfação " + s.Period + " gerado"
+}
+
+// summaryFacts: pares nome/valor mostrados no Teams e no Slack.
+func summaryFacts(s runSummary) [][2]string {
+	if s.Status == "erro" {
+		facts := [][2]string{{"Erro", s.Error}}
+		if s.Host != "" {
+			facts = append(facts, [2]string{"Máquina", s.Host})
+		}
+		return facts
+	}
+	facts := [][2]string{
+		{"Linhas", fmt.Sprintf("%d (%d duplicadas removidas)", s.Rows, s.Skipped)},
+	}
+	for _, k := range s.KPIs {
+		facts = append(facts, [2]string{"KPI", k.Text})
+	}
+	if s.Alerts > 0 {
+		facts = append(facts, [2]string{"Metas não atingidas", fmt.Sprint(s.Alerts)})
+	}
+	if len(s.Paths) > 0 {
+		facts = append(facts, [2]string{"Arquivos", strings.Join(s.Paths, ", ")})
+	}
+	return facts
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func teamsCard(s runSummary) map[string]any {
+	color := "2E7D32"
+	switch s.Status {
+	case "erro":
+		color = "C0392B"
+	case "alerta":
+		color = "E67E22"
+	}
+	var facts []teamsFact
+	for _, f := range summaryFacts(s) {
+		facts = append(facts, teamsFact{Name: f[0], Value: f[1]})
+	}
+	title := summaryHeadline(s)
+	return map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    title,
+		"themeColor": color,
+		"title":      title,
+		"sections":   []map[string]any{{"facts": facts}},
+	}
+}
+
+func slackText(s runSummary) string {
+	var sb strings.Builder
+	sb.WriteString("*" + summaryHeadline(s) + "*")
+	for _, f := range summaryFacts(s) {
+		fmt.Fprintf(&sb, "\n• %s: %s", f[0], f[1])
+	}
+	return sb.String()
+}
+
+func notifyFailure(msg string) {
+	if notifier == nil {
+		return
+	}
+	notifier.notify(runSummary{Status: "erro", Period: notifier.period, Error: msg})
+}
+
+func (w *webhook) setPeriod(period string) {
+	if w != nil {
+		w.period = period
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// periodSpec reúne as flags de período. Só um modo pode ser usado por vez:
+// --start/--end, --month/--year, --quarter, --semester, --week, --last-days ou --ytd.
+// Sem nenhum, vale o mês anterior fechado.
+type periodSpec struct {
+	Start, End string // RFC3339 (trazem o próprio fuso; --tz não se aplica)
+	Month      int
+	Year       int
+	Quarter    int
+	Semester   int
+	Week       string // "2025-W49" ou só o número, com --year
+	LastDays   int
+	YTD        bool
+
+	// Loc é o fuso usado para montar os limites (--tz). Now permite fixar o
+	// "hoje" (zero = time.Now()).
+	Loc *time.Location
+	Now time.Time
+}
+
+// loadTZ resolve --tz. Vazio = fuso da máquina.
+func loadTZ(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --tz %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// resolvePeriod devolve o intervalo [start, end) da consulta.
+func resolvePeriod(p periodSpec) (time.Time, time.Time, error) {
+	loc := p.Loc
+	if loc == nil {
+		loc = time.Local
+	}
+	now := p.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.In(loc)
+	today := startOfDay(now.Year(), now.Month(), now.Day(), loc)
+
+	modes := 0
+	for _, set := range []bool{
+		p.Start != "" || p.End != "",
+		p.Month != 0,
+		p.Quarter != 0,
+		p.Semester != 0,
+		p.Week != "",
+		p.LastDays != 0,
+		p.YTD,
+	} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return time.Time{}, time.Time{}, errors.New("use only one of --start/--end, --month, --quarter, --semester, --week, --last-days, --ytd")
+	}
+
+	if p.Start != "" || p.End != "" {
+		if p.Year != 0 {
+			return time.Time{}, time.Time{}, errors.New("--year cannot be used with --start/--end")
+		}
+		if p.Start == "" || p.End == "" {
+			return time.Time{}, time.Time{}, errors.New("use both --start and --end")
+		}
+		s, err := time.Parse(time.RFC3339, p.Start)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("parse --start: %w", err)
+		}
+		e, err := time.Parse(time.RFC3339, p.End)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("parse --end: %w", err)
+		}
+		if !e.After(s) {
+			return time.Time{}, time.Time{}, errors.New("--end must be after --start")
+		}
+		return s, e, nil
+	}
+
+	// --last-days e --ytd partem de hoje: um --year junto seria ignorado.
+	if p.Year != 0 && (p.LastDays != 0 || p.YTD) {
+		return time.Time{}, time.Time{}, errors.New("--year cannot be used with --last-days or --ytd (they count back from today)")
+	}
+
+	switch {
+	case p.LastDays != 0:
+		// Últimos N dias completos, sem contar hoje.
+		if p.LastDays < 1 || p.LastDays > 3660 {
+			return time.Time{}, time.Time{}, errors.New("--last-days must be 1..3660")
+		}
+		return startOfDay(today.Year(), today.Month(), today.Day()-p.LastDays, loc), today, nil
+
+	case p.YTD:
+		// De 1º de janeiro até ontem (inclusive).
+		s := startOfDay(now.Year(), 1, 1, loc)
+		if !today.After(s) {
+			return time.Time{}, time.Time{}, errors.New("--ytd is empty on January 1st (use --year with --month, or --last-days)")
+		}
+		return s, today, nil
+
+	case p.Week != "":
+		return isoWeekPeriod(p.Week, p.Year, loc)
+	}
+
+	year := p.Year
+	if year == 0 && (p.Quarter != 0 || p.Semester != 0) {
+		year = now.Year()
+	}
+
+	switch {
+	case p.Quarter != 0:
+		if p.Quarter < 1 || p.Quarter > 4 {
+			return time.Time{}, time.Time{}, errors.New("--quarter must be 1..4")
+		}
+		if err := checkYear(year); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		m := time.Month(3*(p.Quarter-1) + 1)
+		return startOfDay(year, m, 1, loc), startOfDay(year, m+3, 1, loc), nil
+
+	case p.Semester != 0:
+		if p.Semester < 1 || p.Semester > 2 {
+			return time.Time{}, time.Time{}, errors.New("--semester must be 1 or 2")
+		}
+		if err := checkYear(year); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		m := time.Month(6*(p.Semester-1) + 1)
+		return startOfDay(year, m, 1, loc), startOfDay(year, m+6, 1, loc), nil
+	}
+
+	if p.Month == 0 && p.Year == 0 {
+		// default: month closed = previous month (no fuso de --tz)
+		return startOfDay(now.Year(), now.Month()-1, 1, loc), startOfDay(now.Year(), now.Month(), 1, loc), nil
+	}
+	if p.Month < 1 || p.Month > 12 {
+		return time.Time{}, time.Time{}, errors.New("--month must be 1..12 (or use --quarter, --semester or --week with --year)")
+	}
+	if err := checkYear(p.Year); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	m := time.Month(p.Month)
+	return startOfDay(p.Year, m, 1, loc), startOfDay(p.Year, m+1, 1, loc), nil
+}
+
+// startOfDay é o primeiro instante do dia civil year-month-day em loc (day e
+// month podem sair da faixa, como em time.Date). Onde o horário de verão
+// começa à meia-noite (Brasil até 2019), 00:00 não existe e time.Date volta
+// para 23:00 da véspera; o dia começa à 01:00.
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	civil := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	t := time.Date(civil.Year(), civil.Month(), civil.Day(), 0, 0, 0, 0, loc)
+	for t.Day() != civil.Day() {
+		t = t.Add(time.Hour)
+	}
+	return t
+}
+
+func checkYear(year int) error {
+	if year < 2000 || year > 2100 {
+		return errors.New("--year must be between 2000 and 2100")
+	}
+	return nil
+}
+
+var isoWeekRE = regexp.MustCompile(`^(\d{4})-?[Ww](\d{1,2})$`)
+
+// isoWeekPeriod: semana ISO 8601 (segunda a domingo; a semana 1 é a que
+// contém o dia 4 de janeiro). Aceita "2025-W49" ou "49" com --year.
+func isoWeekPeriod(week string, year int, loc *time.Location) (time.Time, time.Time, error) {
+	week = strings.TrimSpace(week)
+	var n int
+	if m := isoWeekRE.FindStringSubmatch(week); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		if year != 0 && year != y {
+			return time.Time{}, time.Time{}, fmt.Errorf("--week %s does not match --year %d", week, year)
+		}
+		year = y
+		n, _ = strconv.Atoi(m[2])
+	} else {
+		v, err := strconv.Atoi(week)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --week %q (use 2025-W49, or a week number with --year)", week)
+		}
+		if year == 0 {
+			return time.Time{}, time.Time{}, errors.New("--week with a number needs --year (or use 2025-W49)")
+		}
+		n = v
+	}
+	if err := checkYear(year); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	jan4 := time.Date(year, 1, 4, 12, 0, 0, 0, time.UTC)
+	// Segunda-feira da semana 1 (Weekday: domingo = 0).
+	offset := (int(jan4.Weekday()) + 6) % 7
+	s := startOfDay(year, 1, 4-offset+7*(n-1), loc)
+	if y, w := s.ISOWeek(); n < 1 || y != year || w != n {
+		return time.Time{}, time.Time{}, fmt.Errorf("--week %d does not exist in %d", n, year)
+	}
+	return s, startOfDay(s.Year(), s.Month(), s.Day()+7, loc), nil
+}
+
+// monthAligned diz se [first, last] cobre meses inteiros (last inclusivo).
+func monthAligned(first, last time.Time) bool {
+	end := last.Add(time.Nanosecond)
+	return first.Day() == 1 && first.Equal(startOfDay(first.Year(), first.Month(), 1, first.Location())) &&
+		end.Day() == 1 && end.Equal(startOfDay(end.Year(), end.Month(), 1, end.Location()))
+}
+
+// periodSlug compõe os nomes automáticos de arquivo (last inclusivo):
+// "2025_12" (um mês), "2025_10_a_2025_12" (meses inteiros) ou
+// "2025_12_01_a_2025_12_07" (semana, últimos N dias...).
+func periodSlug(first, last time.Time) string {
+	if monthAligned(first, last) {
+		if sameMonth(first, last) {
+			return fmt.Sprintf("%04d_%02d", first.Year(), int(first.Month()))
+		}
+		return fmt.Sprintf("%04d_%02d_a_%04d_%02d", first.Year(), int(first.Month()), last.Year(), int(last.Month()))
+	}
+	return first.Format("2006_01_02") + "_a_" + last.Format("2006_01_02")
+}
+
+// monthBounds estende [first, last] para meses inteiros (last inclusivo).
+func monthBounds(first, last time.Time) (time.Time, time.Time) {
+	s := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, first.Location())
+	e := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, last.Location()).AddDate(0, 1, 0)
+	return s, e.Add(-time.Nanosecond)
+}
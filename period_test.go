@@ -0,0 +1,183 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // fusos fixos no teste, sem depender do zoneinfo da máquina
+)
+
+func mustLoc(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestResolvePeriod(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	ny := mustLoc(t, "America/New_York")
+	london := mustLoc(t, "Europe/London")
+	at := func(loc *time.Location, y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, loc)
+	}
+	tests := []struct {
+		name       string
+		spec       periodSpec
+		start, end string // RFC3339 no fuso do spec
+		hours      float64
+	}{
+		{"previous month", periodSpec{Loc: sp, Now: at(sp, 2026, 1, 15, 10)},
+			"2025-12-01T00:00:00-03:00", "2026-01-01T00:00:00-03:00", 31 * 24},
+		{"previous month uses --tz, not UTC", periodSpec{Loc: sp, Now: time.Date(2026, 2, 1, 2, 0, 0, 0, time.UTC)},
+			"2025-12-01T00:00:00-03:00", "2026-01-01T00:00:00-03:00", 31 * 24},
+		{"month", periodSpec{Month: 12, Year: 2025, Loc: sp},
+			"2025-12-01T00:00:00-03:00", "2026-01-01T00:00:00-03:00", 31 * 24},
+		{"quarter 4", periodSpec{Quarter: 4, Year: 2025, Loc: sp},
+			"2025-10-01T00:00:00-03:00", "2026-01-01T00:00:00-03:00", 92 * 24},
+		{"quarter defaults to this year", periodSpec{Quarter: 1, Loc: sp, Now: at(sp, 2026, 5, 2, 9)},
+			"2026-01-01T00:00:00-03:00", "2026-04-01T00:00:00-03:00", 90 * 24},
+		{"semester 2", periodSpec{Semester: 2, Year: 2025, Loc: sp},
+			"2025-07-01T00:00:00-03:00", "2026-01-01T00:00:00-03:00", 184 * 24},
+		{"last 7 days", periodSpec{LastDays: 7, Loc: sp, Now: at(sp, 2025, 12, 10, 15)},
+			"2025-12-03T00:00:00-03:00", "2025-12-10T00:00:00-03:00", 7 * 24},
+		{"ytd", periodSpec{YTD: true, Loc: sp, Now: at(sp, 2025, 3, 1, 8)},
+			"2025-01-01T00:00:00-03:00", "2025-03-01T00:00:00-03:00", 59 * 24},
+		{"rfc3339", periodSpec{Start: "2025-12-01T00:00:00Z", End: "2025-12-02T00:00:00Z", Loc: sp},
+			"2025-12-01T00:00:00Z", "2025-12-02T00:00:00Z", 24},
+
+		// Semana ISO: 53 semanas e semana 1 que começa em dezembro.
+		{"iso week 49", periodSpec{Week: "2025-W49", Loc: sp},
+			"2025-12-01T00:00:00-03:00", "2025-12-08T00:00:00-03:00", 7 * 24},
+		{"iso week 53 of 2020", periodSpec{Week: "2020-W53", Loc: sp},
+			"2020-12-28T00:00:00-03:00", "2021-01-04T00:00:00-03:00", 7 * 24},
+		{"iso week 53 of 2026", periodSpec{Week: "53", Year: 2026, Loc: sp},
+			"2026-12-28T00:00:00-03:00", "2027-01-04T00:00:00-03:00", 7 * 24},
+		{"week 1 of 2025 starts in 2024", periodSpec{Week: "2025W01", Loc: sp},
+			"2024-12-30T00:00:00-03:00", "2025-01-06T00:00:00-03:00", 7 * 24},
+		{"week 1 of 2026 starts in 2025", periodSpec{Week: "1", Year: 2026, Loc: sp},
+			"2025-12-29T00:00:00-03:00", "2026-01-05T00:00:00-03:00", 7 * 24},
+		{"week 1 of 2021 starts in January", periodSpec{Week: "2021-W01", Loc: sp},
+			"2021-01-04T00:00:00-03:00", "2021-01-11T00:00:00-03:00", 7 * 24},
+
+		// Horário de verão: os limites ficam na meia-noite local e a duração muda.
+		{"month with DST start (New York)", periodSpec{Month: 3, Year: 2026, Loc: ny},
+			"2026-03-01T00:00:00-05:00", "2026-04-01T00:00:00-04:00", 31*24 - 1},
+		{"month with DST end (London)", periodSpec{Month: 10, Year: 2025, Loc: london},
+			"2025-10-01T00:00:00+01:00", "2025-11-01T00:00:00Z", 31*24 + 1},
+		{"week with DST start (New York)", periodSpec{Week: "2026-W10", Loc: ny},
+			"2026-03-02T00:00:00-05:00", "2026-03-09T00:00:00-04:00", 7*24 - 1},
+		{"last days across DST (New York)", periodSpec{LastDays: 7, Loc: ny, Now: at(ny, 2026, 3, 10, 12)},
+			"2026-03-03T00:00:00-05:00", "2026-03-10T00:00:00-04:00", 7*24 - 1},
+		// Brasil, 2018: o horário de verão começou em 4/11 à meia-noite, então o
+		// dia 4 começou à 01:00 (-02) e 00:00 não existiu.
+		{"last days ending on DST-at-midnight day (Sao Paulo 2018)", periodSpec{LastDays: 3, Loc: sp, Now: at(sp, 2018, 11, 4, 15)},
+			"2018-11-01T00:00:00-03:00", "2018-11-04T01:00:00-02:00", 72},
+		{"last days starting on DST-at-midnight day (Sao Paulo 2018)", periodSpec{LastDays: 1, Loc: sp, Now: at(sp, 2018, 11, 5, 15)},
+			"2018-11-04T01:00:00-02:00", "2018-11-05T00:00:00-02:00", 23},
+		{"week with DST-at-midnight Sunday (Sao Paulo 2018)", periodSpec{Week: "2018-W44", Loc: sp},
+			"2018-10-29T00:00:00-03:00", "2018-11-05T00:00:00-02:00", 7*24 - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, e, err := resolvePeriod(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Format(time.RFC3339); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := e.Format(time.RFC3339); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+			if h := e.Sub(s).Hours(); h != tt.hours {
+				t.Errorf("length = %gh, want %gh", h, tt.hours)
+			}
+		})
+	}
+}
+
+func TestResolvePeriodErrors(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	tests := []struct {
+		name    string
+		spec    periodSpec
+		wantErr string
+	}{
+		{"two modes", periodSpec{Month: 1, Year: 2025, Quarter: 1}, "use only one of"},
+		{"month 13", periodSpec{Month: 13, Year: 2025}, "--month must be 1..12"},
+		{"month without year", periodSpec{Month: 5}, "--year must be between"},
+		{"quarter 5", periodSpec{Quarter: 5, Year: 2025}, "--quarter must be 1..4"},
+		{"start without end", periodSpec{Start: "2025-12-01T00:00:00Z"}, "use both --start and --end"},
+		{"end before start", periodSpec{Start: "2025-12-02T00:00:00Z", End: "2025-12-01T00:00:00Z"}, "--end must be after --start"},
+		{"ytd on January 1st", periodSpec{YTD: true, Loc: sp, Now: time.Date(2026, 1, 1, 9, 0, 0, 0, sp)}, "--ytd is empty"},
+		{"last days 0 range", periodSpec{LastDays: -1}, "--last-days must be"},
+		{"no week 53 in 2025", periodSpec{Week: "2025-W53"}, "--week 53 does not exist in 2025"},
+		{"week 0", periodSpec{Week: "0", Year: 2025}, "--week 0 does not exist"},
+		{"week number without year", periodSpec{Week: "12"}, "needs --year"},
+		{"week and year disagree", periodSpec{Week: "2025-W01", Year: 2024}, "does not match --year 2024"},
+		{"bad week", periodSpec{Week: "semana 3"}, "invalid --week"},
+		{"year with last days", periodSpec{LastDays: 30, Year: 2024}, "--year cannot be used with --last-days or --ytd"},
+		{"year with ytd", periodSpec{YTD: true, Year: 2025}, "--year cannot be used with --last-days or --ytd"},
+		{"year with start", periodSpec{Start: "2025-12-01T00:00:00Z", End: "2025-12-02T00:00:00Z", Year: 2025}, "--year cannot be used with --start/--end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := resolvePeriod(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMonthAligned(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	ny := mustLoc(t, "America/New_York")
+	last := func(end time.Time) time.Time { return end.Add(-time.Nanosecond) }
+	tests := []struct {
+		name        string
+		first, last time.Time
+		want        bool
+		slug        string
+	}{
+		{"one month", time.Date(2025, 12, 1, 0, 0, 0, 0, sp), last(time.Date(2026, 1, 1, 0, 0, 0, 0, sp)), true, "2025_12"},
+		{"quarter", time.Date(2025, 10, 1, 0, 0, 0, 0, sp), last(time.Date(2026, 1, 1, 0, 0, 0, 0, sp)), true, "2025_10_a_2025_12"},
+		{"month across DST", time.Date(2026, 3, 1, 0, 0, 0, 0, ny), last(time.Date(2026, 4, 1, 0, 0, 0, 0, ny)), true, "2026_03"},
+		{"week", time.Date(2025, 12, 1, 0, 0, 0, 0, sp), last(time.Date(2025, 12, 8, 0, 0, 0, 0, sp)), false, "2025_12_01_a_2025_12_07"},
+		{"first not midnight", time.Date(2025, 12, 1, 6, 0, 0, 0, sp), last(time.Date(2026, 1, 1, 0, 0, 0, 0, sp)), false, "2025_12_01_a_2025_12_31"},
+		{"month in UTC seen from -03", time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC).In(sp), last(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)).In(sp), false, "2025_11_30_a_2025_12_31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthAligned(tt.first, tt.last); got != tt.want {
+				t.Errorf("monthAligned = %v, want %v", got, tt.want)
+			}
+			if got := periodSlug(tt.first, tt.last); got != tt.slug {
+				t.Errorf("periodSlug = %q, want %q", got, tt.slug)
+			}
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	// 4/11/2018 começou à 01:00; dia 0 e mês 13 normalizam como time.Date.
+	for _, c := range []struct {
+		y    int
+		m    time.Month
+		d    int
+		want string
+	}{
+		{2018, 11, 4, "2018-11-04T01:00:00-02:00"},
+		{2018, 11, 5, "2018-11-05T00:00:00-02:00"},
+		{2025, 3, 0, "2025-02-28T00:00:00-03:00"},
+		{2025, 13, 1, "2026-01-01T00:00:00-03:00"},
+	} {
+		if got := startOfDay(c.y, c.m, c.d, sp).Format(time.RFC3339); got != c.want {
+			t.Errorf("startOfDay(%d, %d, %d) = %s, want %s", c.y, c.m, c.d, got, c.want)
+		}
+	}
+}
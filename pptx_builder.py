@@ -1,10 +1,31 @@
 import argparse
+import copy
+import io
 import json
 import os
+import unicodedata
+import zipfile
 
 from pptx import Presentation
+from pptx.dml.color import RGBColor
+from pptx.enum.shapes import PP_PLACEHOLDER
 from pptx.util import Inches, Pt
 
+# Placeholders reconhecidos no template, pelo nome da forma no layout
+# (Exibir > Slide Mestre > Painel de Seleção). Sem acento e sem caixa.
+PH_NAMES = {
+    "title": {"title", "titulo"},
+    "chart": {"chart", "grafico"},
+    "period": {"period", "periodo"},
+    "page": {"page", "pagina"},
+}
+
+TITLE_TYPES = (PP_PLACEHOLDER.TITLE, PP_PLACEHOLDER.CENTER_TITLE)
+BODY_TYPES = (PP_PLACEHOLDER.BODY, PP_PLACEHOLDER.OBJECT)
+
+_POTX_CT = b"application/vnd.openxmlformats-officedocument.presentationml.template.main+xml"
+_PPTX_CT = b"application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"
+
 
 def _set_widescreen(prs: Presentation) -> None:
     # 13.333" x 7.5" (16:9)
@@ -27,6 +48,40 @@ def _add_title(slide, text: str) -> None:
     run.font.size = Pt(18)
 
 
+def _add_note(slide, s: dict, left=None, top=None, width=None) -> None:
+    # Nota abaixo do título (ex.: teste de significância); em vermelho se "note_alert".
+    text = (s.get("note") or "").strip()
+    if not text:
+        return
+    left = Inches(0.6) if left is None else left
+    top = Inches(0.72) if top is None else top
+    width = Inches(12.2) if width is None else width
+    box = slide.shapes.add_textbox(left, top, width, Inches(0.3))
+    box.text_frame.word_wrap = True
+    run = box.text_frame.paragraphs[0].add_run()
+    run.text = text
+    run.font.size = Pt(12)
+    run.font.bold = bool(s.get("note_alert"))
+    run.font.color.rgb = RGBColor(0xC0, 0x39, 0x2B) if s.get("note_alert") else RGBColor(0x59, 0x59, 0x59)
+
+
+def _add_alert(slide, s: dict, left, top, width) -> bool:
+    # Faixa vermelha com as metas não atingidas (--targets); False se não houver.
+    text = (s.get("alert") or "").strip()
+    if not text:
+        return False
+    box = slide.shapes.add_textbox(left, top, width, Inches(0.35))
+    box.fill.solid()
+    box.fill.fore_color.rgb = RGBColor(0xC0, 0x39, 0x2B)
+    box.text_frame.word_wrap = True
+    run = box.text_frame.paragraphs[0].add_run()
+    run.text = text
+    run.font.size = Pt(14)
+    run.font.bold = True
+    run.font.color.rgb = RGBColor(0xFF, 0xFF, 0xFF)
+    return True
+
+
 def _add_picture(prs: Presentation, slide, img_path: str) -> None:
     # Place the image below title.
     # Reduce size by ~30% vs previous width (12.2" -> 8.54"), and center it.
@@ -37,6 +92,267 @@ def _add_picture(prs: Presentation, slide, img_path: str) -> None:
     slide.shapes.add_picture(img_path, left, top, width=width)
 
 
+def _add_picture_left(slide, img_path: str) -> None:
+    # With a breakdown table the chart goes to the left half.
+    top = Inches(1.0)
+    left = Inches(0.4)
+    width = Inches(6.6)
+    slide.shapes.add_picture(img_path, left, top, width=width)
+
+
+def _add_table(slide, table: dict, left=None, top=None, width=None) -> None:
+    # Per-month breakdown on the right half: header row + one row per answer.
+    header = table.get("header") or []
+    rows = table.get("rows") or []
+    if not header or not rows:
+        return
+    left = Inches(7.2) if left is None else left
+    top = Inches(1.2) if top is None else top
+    width = Inches(5.8) if width is None else width
+    height = Inches(0.3) * (len(rows) + 1)
+    shape = slide.shapes.add_table(len(rows) + 1, len(header), left, top, width, height)
+    tbl = shape.table
+    for c, text in enumerate(header):
+        tbl.cell(0, c).text = str(text)
+    for r, row in enumerate(rows, start=1):
+        for c, text in enumerate(row[: len(header)]):
+            tbl.cell(r, c).text = str(text)
+    for r in range(len(rows) + 1):
+        for c in range(len(header)):
+            for p in tbl.cell(r, c).text_frame.paragraphs:
+                for run in p.runs:
+                    run.font.size = Pt(11)
+
+
+def _norm(name: str) -> str:
+    s = unicodedata.normalize("NFD", name or "")
+    s = "".join(c for c in s if not unicodedata.combining(c))
+    return s.strip().lower()
+
+
+def _role(shape) -> str:
+    n = _norm(shape.name)
+    for role, names in PH_NAMES.items():
+        if n in names:
+            return role
+    return ""
+
+
+def _open_template(path: str) -> Presentation:
+    # python-pptx só abre .pptx; um .potx difere apenas no content type da
+    # parte principal, então troca em memória.
+    if not path.lower().endswith(".potx"):
+        return Presentation(path)
+    src = io.BytesIO()
+    with zipfile.ZipFile(path) as zin, zipfile.ZipFile(src, "w", zipfile.ZIP_DEFLATED) as zout:
+        for item in zin.infolist():
+            data = zin.read(item.filename)
+            if item.filename == "[Content_Types].xml":
+                data = data.replace(_POTX_CT, _PPTX_CT)
+            zout.writestr(item, data)
+    src.seek(0)
+    return Presentation(src)
+
+
+def _drop_existing_slides(prs: Presentation) -> None:
+    # Slides de exemplo do template não entram no relatório.
+    id_lst = prs.slides._sldIdLst
+    for sld_id in list(id_lst):
+        r_id = sld_id.rId
+        id_lst.remove(sld_id)
+        prs.part.drop_rel(r_id)
+
+
+def _pick_layout(prs: Presentation):
+    # Primeiro layout com placeholder "chart"; senão um com título; senão o último.
+    layouts = list(prs.slide_layouts)
+    for layout in layouts:
+        if any(_role(sh) == "chart" for sh in layout.placeholders):
+            return layout
+    for layout in layouts:
+        if any(_role(sh) == "title" or sh.placeholder_format.type in TITLE_TYPES for sh in layout.placeholders):
+            return layout
+    return layouts[-1]
+
+
+def _slide_shape(slide, layout, role: str):
+    # O nome fica no layout; no slide o placeholder correspondente tem o mesmo
+    # idx. add_slide não copia data, rodapé e número do slide: esses são
+    # clonados do layout aqui.
+    for lsh in layout.placeholders:
+        if _role(lsh) != role:
+            continue
+        idx = lsh.placeholder_format.idx
+        for sh in slide.placeholders:
+            if sh.placeholder_format.idx == idx:
+                return sh
+        el = copy.deepcopy(lsh.element)
+        # id repetido faz o PowerPoint pedir para "reparar" o arquivo.
+        el.xpath("./*/p:cNvPr")[0].set("id", str(slide.shapes._next_shape_id))
+        slide.shapes._spTree.append(el)
+        return slide.shapes[-1]
+    return None
+
+
+def _set_text(shape, text: str) -> None:
+    tf = shape.text_frame
+    tf.text = text
+
+
+def _fit_picture(slide, img_path: str, left: int, top: int, width: int, height: int) -> None:
+    # Mantém a proporção do PNG dentro da área do placeholder, centralizado.
+    pic = slide.shapes.add_picture(img_path, left, top)
+    ratio = min(width / pic.width, height / pic.height)
+    pic.width = int(pic.width * ratio)
+    pic.height = int(pic.height * ratio)
+    pic.left = left + int((width - pic.width) / 2)
+    pic.top = top + int((height - pic.height) / 2)
+
+
+def _add_template_slide(prs: Presentation, layout, s: dict, period: str, page: int) -> None:
+    slide = prs.slides.add_slide(layout)
+    title = (s.get("title") or "").strip()
+
+    title_ph = _slide_shape(slide, layout, "title")
+    if title_ph is None:
+        title_ph = slide.shapes.title
+    if title_ph is not None:
+        _set_text(title_ph, title)
+    elif title:
+        _add_title(slide, title)
+
+    period_ph = _slide_shape(slide, layout, "period")
+    if period_ph is not None:
+        _set_text(period_ph, period)
+    page_ph = _slide_shape(slide, layout, "page")
+    if page_ph is not None:
+        _set_text(page_ph, str(page))
+
+    chart_ph = _slide_shape(slide, layout, "chart")
+    if chart_ph is not None:
+        left, top, width, height = chart_ph.left, chart_ph.top, chart_ph.width, chart_ph.height
+        chart_ph.element.getparent().remove(chart_ph.element)
+    else:
+        left, top = Inches(0.6), Inches(1.0)
+        width, height = prs.slide_width - 2 * left, prs.slide_height - top - Inches(0.6)
+    if (s.get("note") or "").strip():
+        # A nota ocupa o topo da área do gráfico.
+        _add_note(slide, s, left, top, width)
+        top += Inches(0.4)
+        height -= Inches(0.4)
+    if _add_alert(slide, s, left, top, width):
+        top += Inches(0.45)
+        height -= Inches(0.45)
+
+    breakdown = s.get("breakdown")
+    if breakdown:
+        # Gráfico na metade esquerda da área, tabela na direita.
+        half = int(width / 2)
+        _fit_picture(slide, s["image"], left, top, half, height)
+        _add_table(slide, breakdown, left + half, top, width - half)
+    else:
+        _fit_picture(slide, s["image"], left, top, width, height)
+
+    # Placeholders vazios (ex.: subtítulo do layout) aparecem como "Clique para
+    # adicionar texto" no PowerPoint; remove.
+    for sh in list(slide.placeholders):
+        if not sh.has_text_frame or not sh.text_frame.text.strip():
+            sh.element.getparent().remove(sh.element)
+
+
+def _add_text_slide(prs: Presentation, s: dict) -> None:
+    # Capa / metodologia / resumo sem template: caixas de texto no layout em branco.
+    slide = prs.slides.add_slide(prs.slide_layouts[6])
+    title = (s.get("title") or "").strip()
+    if s.get("kind") == "cover":
+        box = slide.shapes.add_textbox(Inches(0.8), Inches(2.6), Inches(11.7), Inches(1.2))
+        p = box.text_frame.paragraphs[0]
+        run = p.add_run()
+        run.text = title
+        run.font.size = Pt(40)
+        run.font.bold = True
+        sub = (s.get("subtitle") or "").strip()
+        if sub:
+            p = box.text_frame.add_paragraph()
+            run = p.add_run()
+            run.text = sub
+            run.font.size = Pt(24)
+        return
+    if title:
+        _add_title(slide, title)
+    box = slide.shapes.add_textbox(Inches(0.8), Inches(1.2), Inches(11.7), Inches(5.6))
+    _fill_bullets(box.text_frame, s.get("bullets") or [], Pt(20))
+
+
+def _fill_bullets(tf, bullets, size=None) -> None:
+    tf.word_wrap = True
+    tf.clear()
+    for i, text in enumerate(bullets):
+        p = tf.paragraphs[0] if i == 0 else tf.add_paragraph()
+        p.text = ("• " if size is not None else "") + text
+        if size is not None:
+            p.space_after = Pt(10)
+            for run in p.runs:
+                run.font.size = size
+
+
+def _pick_text_layout(prs: Presentation, kind: str):
+    # Capa: layout com título centralizado (CENTER_TITLE); texto: título + corpo.
+    layouts = list(prs.slide_layouts)
+    for layout in layouts:
+        types = {sh.placeholder_format.type for sh in layout.placeholders}
+        if kind == "cover" and PP_PLACEHOLDER.CENTER_TITLE in types:
+            return layout
+        if kind != "cover" and PP_PLACEHOLDER.TITLE in types and types & set(BODY_TYPES):
+            return layout
+    return _pick_layout(prs)
+
+
+def _add_template_text_slide(prs: Presentation, s: dict, period: str, page: int) -> None:
+    layout = _pick_text_layout(prs, s.get("kind") or "")
+    slide = prs.slides.add_slide(layout)
+    title = (s.get("title") or "").strip()
+    title_ph = _slide_shape(slide, layout, "title")
+    if title_ph is None:
+        title_ph = slide.shapes.title
+    if title_ph is not None:
+        _set_text(title_ph, title)
+    elif title:
+        _add_title(slide, title)
+
+    # Corpo: primeiro placeholder de texto/subtítulo que não seja um dos nomeados.
+    named = {lsh.placeholder_format.idx for lsh in layout.placeholders if _role(lsh)}
+    body = None
+    for sh in slide.placeholders:
+        t = sh.placeholder_format.type
+        if (t in BODY_TYPES or t == PP_PLACEHOLDER.SUBTITLE) and sh.placeholder_format.idx not in named:
+            body = sh
+            break
+    if s.get("kind") == "cover":
+        lines = [(s.get("subtitle") or "").strip()]
+    else:
+        lines = s.get("bullets") or []
+    if body is not None:
+        # O marcador vem do próprio layout.
+        _fill_bullets(body.text_frame, lines)
+    else:
+        box = slide.shapes.add_textbox(Inches(0.8), Inches(1.2), prs.slide_width - Inches(1.6), Inches(5.0))
+        _fill_bullets(box.text_frame, lines, Pt(20))
+
+    period_ph = _slide_shape(slide, layout, "period")
+    if period_ph is not None:
+        _set_text(period_ph, period)
+    page_ph = _slide_shape(slide, layout, "page")
+    if page_ph is not None:
+        _set_text(page_ph, str(page))
+    chart_ph = _slide_shape(slide, layout, "chart")
+    if chart_ph is not None:
+        chart_ph.element.getparent().remove(chart_ph.element)
+    for sh in list(slide.placeholders):
+        if not sh.has_text_frame or not sh.text_frame.text.strip():
+            sh.element.getparent().remove(sh.element)
+
+
 def main() -> int:
     ap = argparse.ArgumentParser()
     ap.add_argument("--manifest", required=True)
@@ -46,10 +362,30 @@ def main() -> int:
     with open(args.manifest, "r", encoding="utf-8") as f:
         data = json.load(f)
 
+    slides = data.get("slides") or []
+    intro = data.get("intro") or []
+    template = (data.get("template") or "").strip()
+    if template:
+        prs = _open_template(template)
+        _drop_existing_slides(prs)
+        layout = _pick_layout(prs)
+        period = (data.get("period") or "").strip()
+        page = 0
+        for s in intro:
+            page += 1
+            _add_template_text_slide(prs, s, period, page)
+        for s in slides:
+            if not (s.get("image") or "").strip():
+                continue
+            page += 1
+            _add_template_slide(prs, layout, s, period, page)
+        return _save(prs, args.out)
+
     prs = Presentation()
     _set_widescreen(prs)
 
-    slides = data.get("slides") or []
+    for s in intro:
+        _add_text_slide(prs, s)
     for s in slides:
         title = (s.get("title") or "").strip()
         img = (s.get("image") or "").strip()
@@ -60,13 +396,25 @@ def main() -> int:
         slide = prs.slides.add_slide(layout)
         if title:
             _add_title(slide, title)
-        _add_picture(prs, slide, img)
+        _add_note(slide, s)
+        breakdown = s.get("breakdown")
+        if breakdown:
+            _add_picture_left(slide, img)
+            _add_table(slide, breakdown)
+        else:
+            _add_picture(prs, slide, img)
+        # Por cima do rodapé do gráfico, para não empurrar o layout fixo.
+        _add_alert(slide, s, Inches(0.6), prs.slide_height - Inches(0.6), Inches(12.2))
+
+    return _save(prs, args.out)
+
 
-    out_dir = os.path.dirname(os.path.abspath(args.out))
+def _save(prs: Presentation, out: str) -> int:
+    out_dir = os.path.dirname(os.path.abspath(out))
     if out_dir:
         os.makedirs(out_dir, exist_ok=True)
-    prs.save(args.out)
-    print(f"OK: PPTX salvo em {args.out}")
+    prs.save(out)
+    print(f"OK: PPTX salvo em {out}")
     return 0
 
 
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Slides de abertura do deck: capa com o período, "Metodologia" (amostra,
+// coleta, regra de dedupe, perguntas fora dos gráficos) e resumo executivo.
+// O texto sai pronto daqui (no idioma de --lang); o pptx_builder.py só desenha.
+
+// pptxTextSlide é um slide só de texto. Kind "cover" usa Subtitle; "bullets" usa Bullets.
+type pptxTextSlide struct {
+	Kind     string   `json:"kind"`
+	Title    string   `json:"title"`
+	Subtitle string   `json:"subtitle,omitempty"`
+	Bullets  []string `json:"bullets,omitempty"`
+}
+
+// Pergunta de recomendação (Recomendaria esse hospital...?).
+const recommendQuestion = 11
+
+func introSlides(merged *csvMergeResult, first, last time.Time, opts pptxOptions) []pptxTextSlide {
+	im := msgs.Intro
+	skipped := merged.Skipped + opts.ExportSkipped
+	period := msgs.periodRange(first, last)
+
+	cover := pptxTextSlide{Kind: "cover", Title: msgs.periodTitle(first, last), Subtitle: period}
+
+	var dedupe string
+	switch {
+	case !opts.Dedupe:
+		dedupe = im.DedupeOff
+	case opts.DedupeSec <= 0:
+		dedupe = fmt.Sprintf(im.DedupeStrict, skipped)
+	default:
+		dedupe = fmt.Sprintf(im.DedupeRule, opts.DedupeSec, skipped)
+	}
+	excluded := make([]string, 0, len(excludedQuestions))
+	for _, n := range excludedQuestions {
+		excluded = append(excluded, strings.TrimRight(msgs.Header[1+n], " :"))
+	}
+	method := pptxTextSlide{Kind: "bullets", Title: im.MethodologyTitle, Bullets: []string{
+		fmt.Sprintf(im.Sample, merged.Rows, floorCount(merged.Counts)),
+		fmt.Sprintf(im.Collection, period),
+		dedupe,
+		fmt.Sprintf(im.Excluded, strings.Join(excluded, "; ")),
+	}}
+
+	summary := pptxTextSlide{Kind: "bullets", Title: im.SummaryTitle, Bullets: []string{
+		fmt.Sprintf(im.Responses, merged.Rows, skipped),
+	}}
+	ac := merged.Counts
+	h := summarize(ac)
+	if h.HasRecommend {
+		summary.Bullets = append(summary.Bullets, fmt.Sprintf(im.Recommend, h.Recommend))
+	}
+	if h.Best >= 0 {
+		summary.Bullets = append(summary.Bullets, fmt.Sprintf(im.Best, ac.Questions[h.Best].Title, h.BestPct))
+	}
+	if h.Worst >= 0 && h.Worst != h.Best {
+		summary.Bullets = append(summary.Bullets, fmt.Sprintf(im.Worst, ac.Questions[h.Worst].Title, h.WorstPct))
+	}
+	if alerts := evaluateTargets(ac, opts.Targets, opts.MinN); len(alerts) > 0 {
+		summary.Bullets = append(summary.Bullets, fmt.Sprintf(im.Alerts, len(alerts)))
+	}
+	if h.Best >= 0 {
+		summary.Bullets = append(summary.Bullets, im.PositiveNote)
+	}
+
+	return []pptxTextSlide{cover, method, summary}
+}
+
+// highlights são os números do resumo executivo (e do aviso por webhook).
+type highlights struct {
+	Recommend    float64 // % de Sim na pergunta de recomendação
+	HasRecommend bool
+	Best, Worst  int // índice em ac.Questions da pergunta de escala com maior/menor % positivo; -1 se não houver
+	BestPct      float64
+	WorstPct     float64
+}
+
+func summarize(ac *answerCounts) highlights {
+	h := highlights{Best: -1, Worst: -1}
+	for i, qc := range ac.Questions {
+		pct, ok := positiveShare(ac.Total[i])
+		if !ok {
+			continue
+		}
+		if qc.Number == recommendQuestion {
+			h.Recommend, h.HasRecommend = pct, true
+		}
+		if !isRatingQuestion(ac.Total[i]) {
+			continue
+		}
+		if h.Best < 0 || pct > h.BestPct {
+			h.Best, h.BestPct = i, pct
+		}
+		if h.Worst < 0 || pct < h.WorstPct {
+			h.Worst, h.WorstPct = i, pct
+		}
+	}
+	return h
+}
+
+// floorCount conta os andares com pelo menos uma resposta ("" não conta).
+func floorCount(ac *answerCounts) int {
+	n := 0
+	for floor := range ac.ByFloor {
+		if floor != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// positiveShare: % de Excelente/Boa (códigos 4 e 2) ou Sim (6) entre as
+// respostas conhecidas, sem "Não utilizei" (5). ok=false se não houver base.
+func positiveShare(counts map[string]int) (float64, bool) {
+	pos, base := positiveCounts(counts)
+	if base == 0 {
+		return 0, false
+	}
+	return float64(pos) / float64(base) * 100, true
+}
+
+// positiveCounts devolve as respostas positivas e a base de positiveShare.
+func positiveCounts(counts map[string]int) (pos, base int) {
+	for label, c := range counts {
+		code, ok := answerCode(label)
+		if !ok {
+			if _, isCode := msgs.Answers[label]; !isCode {
+				continue
+			}
+			code = label
+		}
+		switch code {
+		case "5":
+			continue
+		case "2", "4", "6":
+			pos += c
+		}
+		base += c
+	}
+	return pos, base
+}
+
+// isRatingQuestion: pergunta de escala (Ruim..Excelente), não de Sim/Não.
+func isRatingQuestion(counts map[string]int) bool {
+	for label := range counts {
+		code, ok := answerCode(label)
+		if !ok {
+			code = label
+		}
+		switch code {
+		case "1", "2", "3", "4":
+			return true
+		}
+	}
+	return false
+}
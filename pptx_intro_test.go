@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// introCounts monta as contagens com uma pergunta por mapa, na ordem de numbers.
+func introCounts(numbers []int, totals ...map[string]int) *answerCounts {
+	ac := &answerCounts{ByFloor: map[string][]map[string]int{"3": nil}}
+	for _, n := range numbers {
+		ac.Questions = append(ac.Questions, questionCol{Number: n, Title: fmt.Sprintf("Q%d", n)})
+	}
+	ac.Total = totals
+	return ac
+}
+
+// scaleCounts: n respostas de escala com exc Excelente e ruim Ruim (o resto Boa).
+func scaleCounts(n, exc, ruim int) map[string]int {
+	return map[string]int{"Excelente": exc, "Ruim": ruim, "Boa": n - exc - ruim}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name         string
+		ac           *answerCounts
+		best, worst  int
+		hasRecommend bool
+		recommend    float64
+	}{
+		{"best and worst", introCounts([]int{1, 2, 11},
+			scaleCounts(10, 9, 0), scaleCounts(10, 2, 5), map[string]int{"Sim": 3, "Não": 1}),
+			0, 1, true, 75},
+		// Uma pergunta de escala só: ela é a melhor e a pior.
+		{"single rating question", introCounts([]int{1, 11},
+			scaleCounts(10, 5, 1), map[string]int{"Sim": 1, "Não": 1}),
+			0, 0, true, 50},
+		// Empate: fica a primeira nas duas pontas.
+		{"tie", introCounts([]int{1, 2}, scaleCounts(10, 5, 0), scaleCounts(10, 5, 0)), 0, 0, false, 0},
+		// Sem a pergunta 11, ou só com "Não utilizei" nela, não há recomendação.
+		{"no recommendation question", introCounts([]int{1, 2}, scaleCounts(10, 9, 0), scaleCounts(10, 2, 5)), 0, 1, false, 0},
+		{"recommendation without base", introCounts([]int{1, 11}, scaleCounts(10, 9, 0), map[string]int{"Não utilizei": 4}), 0, 0, false, 0},
+		// Sim/Não não entra em melhor/pior.
+		{"only yes/no", introCounts([]int{11}, map[string]int{"Sim": 9, "Não": 1}), -1, -1, true, 90},
+		{"no answers", introCounts([]int{1}, map[string]int{}), -1, -1, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := summarize(tt.ac)
+			if h.Best != tt.best || h.Worst != tt.worst {
+				t.Errorf("best = %d, worst = %d; want %d, %d", h.Best, h.Worst, tt.best, tt.worst)
+			}
+			if h.HasRecommend != tt.hasRecommend || h.Recommend != tt.recommend {
+				t.Errorf("recommend = %v, %v; want %v, %v", h.Recommend, h.HasRecommend, tt.recommend, tt.hasRecommend)
+			}
+		})
+	}
+}
+
+func TestIntroSummaryBullets(t *testing.T) {
+	day := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	summary := func(ac *answerCounts) []string {
+		slides := introSlides(&csvMergeResult{Counts: ac, Rows: 10}, day, day.AddDate(0, 1, -1), pptxOptions{})
+		return slides[2].Bullets
+	}
+	count := func(bullets []string, prefix string) int {
+		n := 0
+		for _, b := range bullets {
+			if strings.HasPrefix(b, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Melhor == pior: só a linha de melhor avaliação.
+	got := summary(introCounts([]int{1, 11}, scaleCounts(10, 5, 1), map[string]int{"Sim": 1, "Não": 1}))
+	if count(got, "Melhor avaliação") != 1 || count(got, "Pior avaliação") != 0 || count(got, "Recomendariam") != 1 {
+		t.Errorf("best == worst: %q", got)
+	}
+
+	// Sem recomendação: sem a linha, e o resto continua.
+	got = summary(introCounts([]int{1, 2}, scaleCounts(10, 9, 0), scaleCounts(10, 2, 5)))
+	if count(got, "Recomendariam") != 0 || count(got, "Melhor avaliação") != 1 || count(got, "Pior avaliação") != 1 {
+		t.Errorf("no recommendation: %q", got)
+	}
+
+	// Nada de escala: sem melhor/pior e sem a nota sobre "positivas".
+	got = summary(introCounts([]int{1}, map[string]int{}))
+	if len(got) != 1 || !strings.HasPrefix(got[0], "10 respostas") {
+		t.Errorf("no rating answers: %q", got)
+	}
+}
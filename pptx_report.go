@@ -2,11 +2,9 @@ package main
 
 import (
 	"bytes"
-	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,27 +13,102 @@ import (
 	"time"
 
 	chart "github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
 )
 
 type pptxManifest struct {
-	Title  string          `json:"title"`
-	Slides []pptxSlideSpec `json:"slides"`
+	Title string `json:"title"`
+	// Intro são os slides de abertura (capa, metodologia, resumo), antes dos gráficos.
+	Intro []pptxTextSlide `json:"intro,omitempty"`
+	// Period vai para o placeholder "period" do template (ex.: "01/12/2025 a 31/12/2025").
+	Period string `json:"period"`
+	// Template é o .pptx/.potx corporativo (--pptx-template); vazio = deck em branco.
+	Template string          `json:"template,omitempty"`
+	Slides   []pptxSlideSpec `json:"slides"`
 }
 
 type pptxSlideSpec struct {
-	Title     string `json:"title"`
-	ImagePath string `json:"image"`
+	Title     string     `json:"title"`
+	ImagePath string     `json:"image"`
+	Breakdown *pptxTable `json:"breakdown,omitempty"`
+	// Note aparece abaixo do título (ex.: resultado do --compare); NoteAlert
+	// destaca a nota (diferença significativa).
+	Note      string `json:"note,omitempty"`
+	NoteAlert bool   `json:"note_alert,omitempty"`
+	// Alert: metas não atingidas (--targets), em destaque no slide.
+	Alert string `json:"alert,omitempty"`
 }
 
-func maybeGeneratePPTX(csvPath, pptxFlag string, periodStart time.Time) error {
+// pptxTable vira uma tabela ao lado do gráfico (ex.: quebra por mês).
+type pptxTable struct {
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+// pptxOptions reúne as opções do deck que não dependem do período.
+type pptxOptions struct {
+	// Dedupe/DedupeSec: dedupe ao juntar vários CSVs (mesma regra do export).
+	Dedupe    bool
+	DedupeSec int
+	// MonthBreakdown adiciona a cada slide uma tabela com o % de cada resposta por mês.
+	MonthBreakdown bool
+	// Template: .pptx/.potx com os masters/layouts do hospital (--pptx-template).
+	Template string
+	// Compare: testes de significância (--compare); a nota vai em cada slide.
+	Compare *comparison
+	// Targets: metas por pergunta (--targets); as não atingidas são destacadas.
+	Targets []target
+	// MinN: gráficos com menos respostas saem em cinza com aviso (--min-n).
+	MinN int
+	// Intro liga os slides de capa, metodologia e resumo executivo (--pptx-intro).
+	Intro bool
+	// Heatmap põe o mapa de calor pergunta × andar antes das pizzas (--pptx-heatmap).
+	Heatmap bool
+	// Drivers põe os fatores da recomendação logo após o mapa de calor (--pptx-drivers).
+	Drivers bool
+	// ExportSkipped: duplicadas já removidas no export que gerou o CSV, para a
+	// metodologia contar o total (o CSV lido já vem sem elas).
+	ExportSkipped int
+}
+
+// pptxPathFor resolve o --pptx do período ("auto" = defaultPPTXName).
+func pptxPathFor(pptxFlag string, first, last time.Time) string {
+	pptxFlag = strings.TrimSpace(pptxFlag)
+	if strings.EqualFold(pptxFlag, "auto") {
+		return defaultPPTXName(first, last)
+	}
+	return pptxFlag
+}
+
+// maybeGeneratePPTX monta o deck com as respostas de um ou mais CSVs.
+// first/last delimitam o período do título; se forem zero, o período vem das
+// datas lidas.
+func maybeGeneratePPTX(in *periodAnswers, pptxFlag string, first, last time.Time, opts pptxOptions) error {
 	pptxFlag = strings.TrimSpace(pptxFlag)
 	if pptxFlag == "" {
 		return nil
 	}
 
+	merged, err := in.load()
+	if err != nil {
+		return err
+	}
+	if first.IsZero() {
+		first, last = merged.First, merged.Last
+		if first.IsZero() {
+			// CSV sem Data - Criação: sem como saber o período.
+			first, last = time.Now(), time.Now()
+		}
+		// Título e nome por meses inteiros, não pela data da primeira/última resposta.
+		first, last = monthBounds(first, last)
+	}
+	if len(in.Paths) > 1 {
+		fmt.Printf("OK: %d CSVs combinados, %d linhas (removidas %d duplicadas consecutivas)\n", len(in.Paths), merged.Rows, merged.Skipped)
+	}
+
 	pptxPath := pptxFlag
 	if strings.EqualFold(pptxFlag, "auto") {
-		pptxPath = defaultPPTXName(periodStart)
+		pptxPath = defaultPPTXName(first, last)
 	}
 
 	absPPTX := mustAbs(pptxPath)
@@ -44,18 +117,44 @@ func maybeGeneratePPTX(csvPath, pptxFlag string, periodStart time.Time) error {
 		return fmt.Errorf("create png dir: %w", err)
 	}
 
-	slides, err := buildPiePNGsFromCSV(csvPath, pngDir)
+	opts.Compare = opts.Compare.withCurrent(first, last)
+	slides, err := buildPiePNGs(merged.Counts, pngDir, opts)
 	if err != nil {
 		return err
 	}
 	if len(slides) == 0 {
 		return errors.New("no slides generated (no data?)")
 	}
+	if opts.Drivers {
+		drv, ok, err := driversSlide(merged.Counts, pngDir, opts.MinN)
+		if err != nil {
+			return err
+		}
+		if ok {
+			slides = append([]pptxSlideSpec{drv}, slides...)
+		}
+	}
+	if opts.Heatmap {
+		heat, ok, err := heatmapSlide(merged.Counts, pngDir, opts.MinN)
+		if err != nil {
+			return err
+		}
+		if ok {
+			slides = append([]pptxSlideSpec{heat}, slides...)
+		}
+	}
 
 	manifest := pptxManifest{
-		Title:  fmt.Sprintf("Relatório %04d-%02d", periodStart.Year(), int(periodStart.Month())),
+		Title:  msgs.periodTitle(first, last),
+		Period: msgs.periodRange(first, last),
 		Slides: slides,
 	}
+	if opts.Template != "" {
+		manifest.Template = mustAbs(opts.Template)
+	}
+	if opts.Intro {
+		manifest.Intro = introSlides(merged, first, last, opts)
+	}
 	manifestPath := filepath.Join(pngDir, "manifest.json")
 	b, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
@@ -66,6 +165,7 @@ func maybeGeneratePPTX(csvPath, pptxFlag string, periodStart time.Time) error {
 	}
 
 	if err := runPythonPPTXBuilder(manifestPath, absPPTX); err != nil {
+		_ = os.Remove(absPPTX) // o builder pode ter parado no meio do save
 		return err
 	}
 
@@ -73,74 +173,51 @@ func maybeGeneratePPTX(csvPath, pptxFlag string, periodStart time.Time) error {
 	return nil
 }
 
-func defaultPPTXName(periodStart time.Time) string {
-	return fmt.Sprintf("relatorio_%04d_%02d.pptx", periodStart.Year(), int(periodStart.Month()))
-}
-
-func buildPiePNGsFromCSV(csvPath, pngDir string) ([]pptxSlideSpec, error) {
-	f, err := os.Open(csvPath)
-	if err != nil {
-		return nil, fmt.Errorf("open csv: %w", err)
+// checkPPTXTemplate valida --pptx-template antes de consultar o banco.
+func checkPPTXTemplate(path string) error {
+	if path == "" {
+		return nil
 	}
-	defer f.Close()
-
-	r := csv.NewReader(f)
-	r.Comma = ';'
-	r.FieldsPerRecord = -1
-
-	headerRow, err := r.Read()
-	if err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pptx", ".potx":
+	default:
+		return fmt.Errorf("--pptx-template must be a .pptx or .potx file: %s", path)
 	}
-	if len(headerRow) > 0 {
-		headerRow[0] = strings.TrimPrefix(headerRow[0], "\ufeff") // handle UTF-8 BOM
+	st, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("--pptx-template: %w", err)
 	}
-
-	// Expected layout from our exporter:
-	// 0 ANDAR
-	// 1 Paciente
-	// 2..21 questao1..questao20
-	// 22 Data - Criação
-	// 23 Cadastrador
-	if len(headerRow) < 24 {
-		return nil, fmt.Errorf("csv has %d columns; expected >= 24", len(headerRow))
+	if st.IsDir() {
+		return fmt.Errorf("--pptx-template is a directory: %s", path)
 	}
+	return nil
+}
 
-	questionCols := questionColumns(headerRow)
+// defaultPPTXName segue o defaultOutName: relatorio_2025_12.pptx,
+// relatorio_2025_10_a_2025_12.pptx...
+func defaultPPTXName(first, last time.Time) string {
+	return "relatorio_" + periodSlug(first, last) + ".pptx"
+}
 
-	counts := make([]map[string]int, len(questionCols))
-	for i := range counts {
-		counts[i] = map[string]int{}
-	}
+func sameMonth(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
 
-	for {
-		row, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("read csv: %w", err)
-		}
-		for i, qc := range questionCols {
-			if qc.Index >= len(row) {
-				continue
-			}
-			v := strings.TrimSpace(row[qc.Index])
-			if v == "" {
-				continue
-			}
-			v = replaceValue(v) // normalize numeric codes when present
-			counts[i][v]++
-		}
+func buildPiePNGs(ac *answerCounts, pngDir string, opts pptxOptions) ([]pptxSlideSpec, error) {
+	months := ac.months()
+	var cmpResults []compareResult
+	if opts.Compare != nil {
+		cmpResults = opts.Compare.results(ac)
 	}
+	alerts := evaluateTargets(ac, opts.Targets, opts.MinN)
 
-	slides := make([]pptxSlideSpec, 0, len(questionCols))
-	for i, qc := range questionCols {
-		values := counts[i]
+	slides := make([]pptxSlideSpec, 0, len(ac.Questions))
+	for i, qc := range ac.Questions {
+		values := ac.Total[i]
 		if len(values) == 0 {
 			continue
 		}
-		pngBytes, err := renderPiePNG(values)
+		pngBytes, err := renderPiePNG(values, opts.MinN)
 		if err != nil {
 			return nil, fmt.Errorf("render pie for %s: %w", qc.Title, err)
 		}
@@ -149,35 +226,231 @@ func buildPiePNGsFromCSV(csvPath, pngDir string) ([]pptxSlideSpec, error) {
 		if err := os.WriteFile(imgPath, pngBytes, 0o644); err != nil {
 			return nil, fmt.Errorf("write png %s: %w", imgName, err)
 		}
-		slides = append(slides, pptxSlideSpec{Title: qc.Title, ImagePath: imgPath})
+		slide := pptxSlideSpec{Title: qc.Title, ImagePath: imgPath}
+		if len(ac.Units) > 1 {
+			// Deck consolidado: a comparação entre unidades vem antes da por mês.
+			slide.Breakdown = unitBreakdownTable(ac, i)
+		} else if opts.MonthBreakdown && len(months) > 1 {
+			slide.Breakdown = monthBreakdownTable(ac, i, months)
+		}
+		if opts.Compare != nil {
+			slide.Note, slide.NoteAlert = opts.Compare.slideNote(cmpResults, qc.Number)
+		}
+		slide.Alert = slideAlert(alerts, qc.Number)
+		slides = append(slides, slide)
 	}
 
 	return slides, nil
 }
 
+// monthBreakdownTable: uma linha por resposta, uma coluna por mês, com o % do mês.
+func monthBreakdownTable(ac *answerCounts, qi int, months []string) *pptxTable {
+	labels := make([]string, len(months))
+	counts := make([]map[string]int, len(months))
+	for m, key := range months {
+		labels[m] = msgs.shortMonth(key)
+		counts[m] = ac.ByMonth[key][qi]
+	}
+	return breakdownTable(ac.Total[qi], labels, counts)
+}
+
+// unitBreakdownTable: idem, uma coluna por unidade (deck consolidado do --units).
+func unitBreakdownTable(ac *answerCounts, qi int) *pptxTable {
+	counts := make([]map[string]int, len(ac.Units))
+	for u, name := range ac.Units {
+		counts[u] = ac.ByUnit[name][qi]
+	}
+	return breakdownTable(ac.Total[qi], ac.Units, counts)
+}
+
+// breakdownTable monta a tabela com o % de cada resposta em cada coluna e o n
+// na última linha.
+func breakdownTable(total map[string]int, labels []string, counts []map[string]int) *pptxTable {
+	answers := sortedAnswers(total)
+	t := &pptxTable{Header: append([]string{""}, labels...)}
+	totals := make([]int, len(counts))
+	for i, c := range counts {
+		for _, n := range c {
+			totals[i] += n
+		}
+	}
+	for _, a := range answers {
+		row := []string{a}
+		for i, c := range counts {
+			if totals[i] == 0 {
+				row = append(row, "-")
+				continue
+			}
+			pct := float64(c[a]) / float64(totals[i]) * 100
+			row = append(row, fmt.Sprintf("%.1f%%", pct))
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	nRow := []string{"n"}
+	for _, n := range totals {
+		nRow = append(nRow, fmt.Sprintf("%d", n))
+	}
+	t.Rows = append(t.Rows, nRow)
+	return t
+}
+
+// sortedAnswers ordena as respostas como no gráfico: mais frequente primeiro.
+func sortedAnswers(counts map[string]int) []string {
+	out := make([]string, 0, len(counts))
+	for k := range counts {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if counts[out[i]] != counts[out[j]] {
+			return counts[out[i]] > counts[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// answerCounts guarda a contagem de respostas por pergunta, no total e por andar.
+// Total[i] e ByFloor[andar][i] correspondem a Questions[i].
+type answerCounts struct {
+	Questions []questionCol
+	Total     []map[string]int
+	ByFloor   map[string][]map[string]int
+	ByMonth   map[string][]map[string]int // chave "2006-01"
+	ByUnit    map[string][]map[string]int // só no CSV consolidado do --units
+	Units     []string                    // chaves de ByUnit, na ordem em que apareceram
+	Drivers   map[int]*driverStat         // por número de pergunta (drivers.go)
+
+	layout csvLayout
+}
+
+func newAnswerCounts(layout csvLayout) *answerCounts {
+	return &answerCounts{
+		Questions: layout.Questions,
+		Total:     newCountMaps(len(layout.Questions)),
+		ByFloor:   map[string][]map[string]int{},
+		ByMonth:   map[string][]map[string]int{},
+		ByUnit:    map[string][]map[string]int{},
+		Drivers:   map[int]*driverStat{},
+		layout:    layout,
+	}
+}
+
+func newCountMaps(n int) []map[string]int {
+	m := make([]map[string]int, n)
+	for i := range m {
+		m[i] = map[string]int{}
+	}
+	return m
+}
+
+// add conta uma linha do CSV; unit vem da coluna Unidade ("" fora do --units).
+func (ac *answerCounts) add(row []string, unit string) {
+	floor := ac.layout.field(row, ac.layout.Floor)
+	var byUnit []map[string]int
+	if unit != "" {
+		if _, ok := ac.ByUnit[unit]; !ok {
+			ac.Units = append(ac.Units, unit)
+		}
+		byUnit = ac.segment(ac.ByUnit, unit)
+		if floor != "" {
+			floor = unit + "/" + floor // o 3º andar de cada unidade é outro andar
+		}
+	}
+	byFloor := ac.segment(ac.ByFloor, floor)
+	var byMonth []map[string]int
+	// O mês é o da hora de parede gravada; o fuso não muda o resultado.
+	if t, ok := parseCreated(ac.layout.field(row, ac.layout.Created), time.UTC); ok {
+		byMonth = ac.segment(ac.ByMonth, t.Format("2006-01"))
+	}
+	for i, qc := range ac.Questions {
+		v := ac.layout.field(row, qc.Index)
+		if v == "" {
+			continue
+		}
+		v = replaceValue(v) // normalize numeric codes when present
+		ac.Total[i][v]++
+		byFloor[i][v]++
+		if byMonth != nil {
+			byMonth[i][v]++
+		}
+		if byUnit != nil {
+			byUnit[i][v]++
+		}
+	}
+	ac.addDrivers(row)
+}
+
+func (ac *answerCounts) segment(m map[string][]map[string]int, key string) []map[string]int {
+	s, ok := m[key]
+	if !ok {
+		s = newCountMaps(len(ac.Questions))
+		m[key] = s
+	}
+	return s
+}
+
+// months devolve as chaves de ByMonth em ordem.
+func (ac *answerCounts) months() []string {
+	out := make([]string, 0, len(ac.ByMonth))
+	for k := range ac.ByMonth {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// countAnswersFromCSV conta um único CSV, sem dedupe (usado pelo --publish
+// logo após o export, que já saiu sem duplicadas). Só conta: com um arquivo e
+// sem dedupe, o fuso das datas não muda nada.
+func countAnswersFromCSV(csvPath string) (*answerCounts, error) {
+	merged, err := readCSVs([]string{csvPath}, nil, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	return merged.Counts, nil
+}
+
 type questionCol struct {
 	Number int
 	Index  int
 	Title  string
 }
 
-func questionColumns(headerRow []string) []questionCol {
+// excludedQuestions são as perguntas de resposta aberta, sem gráfico.
+var excludedQuestions = []int{16, 20}
+
+func isExcludedQuestion(n int) bool {
+	for _, e := range excludedQuestions {
+		if n == e {
+			return true
+		}
+	}
+	return false
+}
+
+// questionColumns lista as perguntas que viram gráfico, com o índice do layout
+// do exporter (mapCSVHeader ajusta o índice para o CSV lido).
+func questionColumns() []questionCol {
 	// Exclude:
 	// - questao16 => CSV index 17
 	// - questao20 => CSV index 21
 	// Mapping: questaoN => index = 1 + N (because 0 andar, 1 paciente)
+	// O título vem do catálogo do idioma ativo (--lang), não do cabeçalho do CSV,
+	// para que um CSV em pt-BR possa gerar um PPTX em outro idioma.
 	cols := make([]questionCol, 0, 18)
 	for n := 1; n <= 20; n++ {
 		idx := 1 + n
-		if n == 16 || n == 20 {
+		if isExcludedQuestion(n) {
 			continue
 		}
-		cols = append(cols, questionCol{Number: n, Index: idx, Title: strings.TrimSpace(headerRow[idx])})
+		cols = append(cols, questionCol{Number: n, Index: idx, Title: msgs.Header[idx]})
 	}
 	return cols
 }
 
-func renderPiePNG(counts map[string]int) ([]byte, error) {
+// renderPiePNG desenha a pizza com n no título e o IC de Wilson (95%) de cada
+// fatia. Com n < minN as fatias saem em tons de cinza e o título avisa.
+func renderPiePNG(counts map[string]int, minN int) ([]byte, error) {
 	total := 0
 	for _, c := range counts {
 		total += c
@@ -201,17 +474,43 @@ func renderPiePNG(counts map[string]int) ([]byte, error) {
 		return items[i].K < items[j].K
 	})
 
+	lowN := total < minN
 	values := make([]chart.Value, 0, len(items))
-	for _, it := range items {
+	for i, it := range items {
 		pct := (float64(it.V) / float64(total)) * 100
-		label := fmt.Sprintf("%s (%d - %.1f%%)", it.K, it.V, pct)
-		values = append(values, chart.Value{Value: float64(it.V), Label: label})
+		lo, hi := wilson(it.V, total)
+		label := fmt.Sprintf("%s (%d - %.1f%% [%.0f-%.0f%%])", it.K, it.V, pct, lo*100, hi*100)
+		v := chart.Value{Value: float64(it.V), Label: label}
+		if lowN {
+			v.Style = chart.Style{FillColor: greyShade(i, len(items)), StrokeColor: drawing.ColorWhite}
+		}
+		values = append(values, v)
 	}
 
+	title := fmt.Sprintf(msgs.ChartN, total)
+	if lowN {
+		title = fmt.Sprintf(msgs.ChartLowN, total, minN)
+	}
+	const width, height, titleH = 1024, 768, 50
 	pie := chart.PieChart{
-		Width:  1024,
-		Height: 768,
-		Values: values,
+		Width:  width,
+		Height: height,
+		// O Title do go-chart é desenhado por cima da pizza; o n vai numa
+		// faixa própria acima dela.
+		Background: chart.Style{Padding: chart.Box{Top: titleH, Left: 10, Right: 10, Bottom: 10}},
+		Values:     values,
+		Elements: []chart.Renderable{func(r chart.Renderer, _ chart.Box, defaults chart.Style) {
+			style := chart.Style{
+				FontSize:            16,
+				FontColor:           drawing.ColorBlack,
+				TextHorizontalAlign: chart.TextHorizontalAlignCenter,
+				TextVerticalAlign:   chart.TextVerticalAlignMiddle,
+			}.InheritFrom(defaults)
+			if lowN {
+				style.FontColor = drawing.Color{R: 200, G: 60, B: 40, A: 255}
+			}
+			chart.Draw.TextWithin(r, title, chart.Box{Top: 0, Left: 0, Right: width, Bottom: titleH}, style)
+		}},
 	}
 
 	var buf bytes.Buffer
@@ -221,6 +520,15 @@ func renderPiePNG(counts map[string]int) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// greyShade: do cinza-escuro ao cinza-claro, para as fatias de amostra pequena.
+func greyShade(i, n int) drawing.Color {
+	v := uint8(90)
+	if n > 1 {
+		v = uint8(90 + (200-90)*i/(n-1))
+	}
+	return drawing.Color{R: v, G: v, B: v, A: 255}
+}
+
 func runPythonPPTXBuilder(manifestPath, pptxOutPath string) error {
 	py := pythonExecutablePath()
 	script := "pptx_builder.py"
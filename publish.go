@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Publicação das contagens mensais numa tabela de relatório, para que os
+// dashboards leiam o histórico direto do banco.
+//
+// Chave: (periodo, questao, resposta, segmento). Rodar de novo o mesmo período
+// atualiza as linhas existentes e remove, pela chave, as que deixaram de
+// existir (ex.: uma resposta que sumiu depois de corrigir o dedupe), então o
+// passo é idempotente.
+
+const defaultPublishTable = "relatorio_respostas_mensal"
+
+var tableNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// publishTarget diz onde gravar: "source" (mesmo banco da consulta),
+// "mysql:<dsn>", "postgres://..." ou "sqlite:<arquivo>".
+type publishTarget struct {
+	Engine string // mysql | postgres | sqlite
+	DSN    string // vazio quando Source
+	Source bool
+}
+
+func parsePublishTarget(s, sourceEngine string) (publishTarget, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.EqualFold(s, "source"):
+		return publishTarget{Engine: sourceEngine, Source: true}, nil
+	case strings.HasPrefix(s, "sqlite:"):
+		if strings.TrimPrefix(s, "sqlite:") == "" {
+			return publishTarget{}, errors.New("--publish=sqlite: needs a file path")
+		}
+		return publishTarget{Engine: engineSQLite, DSN: s}, nil
+	case strings.HasPrefix(s, "postgres://"), strings.HasPrefix(s, "postgresql://"):
+		return publishTarget{Engine: enginePostgres, DSN: s}, nil
+	case strings.HasPrefix(s, "mysql:"):
+		d := strings.TrimPrefix(s, "mysql:")
+		if d == "" {
+			return publishTarget{}, errors.New("--publish=mysql: needs a DSN")
+		}
+		return publishTarget{Engine: engineMySQL, DSN: d}, nil
+	}
+	return publishTarget{}, fmt.Errorf("invalid --publish %q (use source, mysql:<dsn>, postgres://... or sqlite:<file>)", s)
+}
+
+// periodLabel identifica o período na tabela: "2025-12" para um mês fechado,
+// senão "2025-12-01_2026-01-01" (fim exclusivo).
+func periodLabel(start, end time.Time) string {
+	if start.Day() == 1 && start.Hour() == 0 && start.Minute() == 0 && start.Second() == 0 &&
+		start.AddDate(0, 1, 0).Equal(end) {
+		return fmt.Sprintf("%04d-%02d", start.Year(), int(start.Month()))
+	}
+	return start.Format("2006-01-02") + "_" + end.Format("2006-01-02")
+}
+
+type publishRow struct {
+	Questao  int
+	Titulo   string
+	Resposta string // código 1..7 quando conhecido, senão o valor lido
+	Rotulo   string
+	Segmento string
+	Total    int
+}
+
+func publishRows(ac *answerCounts, floors bool) []publishRow {
+	var out []publishRow
+	add := func(segment string, counts []map[string]int) {
+		for i, qc := range ac.Questions {
+			keys := make([]string, 0, len(counts[i]))
+			for k := range counts[i] {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, label := range keys {
+				resp := label
+				if code, ok := answerCode(label); ok {
+					resp = code
+				}
+				out = append(out, publishRow{
+					Questao:  qc.Number,
+					Titulo:   qc.Title,
+					Resposta: resp,
+					Rotulo:   label,
+					Segmento: segment,
+					Total:    counts[i][label],
+				})
+			}
+		}
+	}
+	add("geral", ac.Total)
+	if floors {
+		floorsSorted := make([]string, 0, len(ac.ByFloor))
+		for f := range ac.ByFloor {
+			floorsSorted = append(floorsSorted, f)
+		}
+		sort.Strings(floorsSorted)
+		for _, f := range floorsSorted {
+			add(floorSegment(f), ac.ByFloor[f])
+		}
+	}
+	return out
+}
+
+func floorSegment(floor string) string {
+	if floor == "" {
+		return "andar:-"
+	}
+	return "andar:" + floor
+}
+
+func publishDDL(engine, table string) string {
+	// Postgres não tem DATETIME.
+	datetime := "DATETIME "
+	if engine == enginePostgres {
+		datetime = "TIMESTAMP"
+	}
+	return `CREATE TABLE IF NOT EXISTS ` + table + ` (
+    periodo       VARCHAR(32)  NOT NULL,
+    questao       INT          NOT NULL,
+    resposta      VARCHAR(191) NOT NULL,
+    segmento      VARCHAR(64)  NOT NULL,
+    titulo        VARCHAR(255) NOT NULL,
+    rotulo        VARCHAR(191) NOT NULL,
+    quantidade    INT          NOT NULL,
+    atualizado_em ` + datetime + `    NOT NULL,
+    PRIMARY KEY (periodo, questao, resposta, segmento)
+)`
+}
+
+func publishUpsert(engine, table string) string {
+	insert := `INSERT INTO ` + table + ` (periodo, questao, resposta, segmento, titulo, rotulo, quantidade, atualizado_em)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if engine != engineMySQL {
+		return insert + `
+ON CONFLICT (periodo, questao, resposta, segmento) DO UPDATE SET
+    titulo = excluded.titulo, rotulo = excluded.rotulo,
+    quantidade = excluded.quantidade, atualizado_em = excluded.atualizado_em`
+	}
+	return insert + `
+ON DUPLICATE KEY UPDATE
+    titulo = VALUES(titulo), rotulo = VALUES(rotulo),
+    quantidade = VALUES(quantidade), atualizado_em = VALUES(atualizado_em)`
+}
+
+// publishCounts grava as contagens de um período numa transação e devolve
+// quantas linhas foram gravadas.
+func publishCounts(ctx context.Context, db *sql.DB, engine, table, period string, ac *answerCounts, floors bool) (int, error) {
+	if !tableNameRE.MatchString(table) {
+		return 0, fmt.Errorf("invalid --publish-table %q", table)
+	}
+	if _, err := db.ExecContext(ctx, publishDDL(engine, table)); err != nil {
+		return 0, fmt.Errorf("create table %s: %w", table, err)
+	}
+
+	runAt := time.Now().Truncate(time.Second) // DATETIME do MySQL não guarda fração
+	rows := publishRows(ac, floors)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, rebind(engine, publishUpsert(engine, table)))
+	if err != nil {
+		return 0, fmt.Errorf("prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	written := make(map[publishKey]bool, len(rows))
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, period, r.Questao, r.Resposta, r.Segmento, r.Titulo, r.Rotulo, r.Total, runAt); err != nil {
+			return 0, fmt.Errorf("upsert q%d %q %s: %w", r.Questao, r.Resposta, r.Segmento, err)
+		}
+		written[publishKey{r.Questao, r.Resposta, r.Segmento}] = true
+	}
+
+	// Remove o que não veio nesta execução, pela chave e não por atualizado_em:
+	// o relógio de outra máquina que publicou antes (ou de duas execuções no
+	// mesmo segundo) não pode apagar nem poupar linha. Sem --publish-floors,
+	// as linhas por andar de uma execução anterior ficam como estão.
+	stale, err := staleKeys(ctx, tx, engine, table, period, floors, written)
+	if err != nil {
+		return 0, err
+	}
+	del := `DELETE FROM ` + table + ` WHERE periodo = ? AND questao = ? AND resposta = ? AND segmento = ?`
+	for _, k := range stale {
+		if _, err := tx.ExecContext(ctx, rebind(engine, del), period, k.Questao, k.Resposta, k.Segmento); err != nil {
+			return 0, fmt.Errorf("delete stale q%d %q %s: %w", k.Questao, k.Resposta, k.Segmento, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return len(rows), nil
+}
+
+// publishKey é a chave primária da tabela, sem o período.
+type publishKey struct {
+	Questao  int
+	Resposta string
+	Segmento string
+}
+
+// staleKeys lista as chaves do período que já estão na tabela e não foram
+// gravadas agora. A leitura termina antes dos DELETEs (o MySQL não aceita
+// outro comando na conexão com um resultado aberto).
+func staleKeys(ctx context.Context, tx *sql.Tx, engine, table, period string, floors bool, written map[publishKey]bool) ([]publishKey, error) {
+	q := `SELECT questao, resposta, segmento FROM ` + table + ` WHERE periodo = ?`
+	if !floors {
+		q += ` AND segmento = 'geral'`
+	}
+	rows, err := tx.QueryContext(ctx, rebind(engine, q), period)
+	if err != nil {
+		return nil, fmt.Errorf("list published rows: %w", err)
+	}
+	defer rows.Close()
+	var stale []publishKey
+	for rows.Next() {
+		var k publishKey
+		if err := rows.Scan(&k.Questao, &k.Resposta, &k.Segmento); err != nil {
+			return nil, fmt.Errorf("list published rows: %w", err)
+		}
+		if !written[k] {
+			stale = append(stale, k)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list published rows: %w", err)
+	}
+	return stale, nil
+}
+
+// runPublish conta as respostas do CSV exportado (mesma contagem dos gráficos)
+// e grava no destino de --publish.
+func runPublish(ctx context.Context, source *sql.DB, target publishTarget, table, csvPath, period string, floors bool) error {
+	ac, err := countAnswersFromCSV(csvPath)
+	if err != nil {
+		return err
+	}
+
+	db := source
+	if !target.Source {
+		db, err = openSource(target.Engine, target.DSN)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", target.Engine, err)
+		}
+		defer db.Close()
+	}
+
+	n, err := publishCounts(ctx, db, target.Engine, table, period, ac, floors)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("OK: %d contagens publicadas em %s (período %s)\n", n, table, period)
+	return nil
+}
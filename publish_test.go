@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPublishCountsDeletesByKey(t *testing.T) {
+	db, err := openSource(engineSQLite, "sqlite:"+filepath.Join(t.TempDir(), "pub.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	const table = "relatorio_respostas_mensal"
+
+	counts := func(total map[string]int, floor3 map[string]int) *answerCounts {
+		return &answerCounts{
+			Questions: []questionCol{{Number: 1, Title: "RECEPÇÃO"}},
+			Total:     []map[string]int{total},
+			ByFloor:   map[string][]map[string]int{"3": {floor3}},
+		}
+	}
+	keys := func() map[publishKey]int {
+		rows, err := db.Query(`SELECT questao, resposta, segmento, quantidade FROM ` + table + ` WHERE periodo = '2025-12'`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		out := map[publishKey]int{}
+		for rows.Next() {
+			var k publishKey
+			var n int
+			if err := rows.Scan(&k.Questao, &k.Resposta, &k.Segmento, &n); err != nil {
+				t.Fatal(err)
+			}
+			out[k] = n
+		}
+		return out
+	}
+
+	if _, err := publishCounts(ctx, db, engineSQLite, table, "2025-12", counts(map[string]int{"Excelente": 5, "Ruim": 1}, map[string]int{"Excelente": 2}), true); err != nil {
+		t.Fatal(err)
+	}
+	// Linha de outro período e linha "do futuro" (relógio adiantado de outra
+	// máquina): a primeira fica, a segunda sai por não estar no conjunto gravado.
+	for _, q := range []string{
+		`INSERT INTO ` + table + ` VALUES ('2025-11', 1, '4', 'geral', 'RECEPÇÃO', 'Excelente', 9, '2025-12-01 00:00:00')`,
+		`INSERT INTO ` + table + ` VALUES ('2025-12', 1, '3', 'geral', 'RECEPÇÃO', 'Regular', 7, '2999-01-01 00:00:00')`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Segunda publicação sem --publish-floors: Ruim e a linha do futuro somem,
+	// o andar 3 continua.
+	n, err := publishCounts(ctx, db, engineSQLite, table, "2025-12", counts(map[string]int{"Excelente": 6}, map[string]int{"Excelente": 3}), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d rows written, want 1", n)
+	}
+	got := keys()
+	want := map[publishKey]int{
+		{1, "4", "geral"}:   6,
+		{1, "4", "andar:3"}: 2,
+	}
+	if len(got) != len(want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%v = %d, want %d (all: %v)", k, got[k], v, got)
+		}
+	}
+	var other int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table + ` WHERE periodo = '2025-11'`).Scan(&other); err != nil || other != 1 {
+		t.Errorf("other period rows = %d (%v), want 1", other, err)
+	}
+
+	// Com --publish-floors, o andar também é substituído pelo conjunto novo.
+	if _, err := publishCounts(ctx, db, engineSQLite, table, "2025-12", counts(map[string]int{"Excelente": 6}, map[string]int{"Boa": 1}), true); err != nil {
+		t.Fatal(err)
+	}
+	got = keys()
+	if _, ok := got[publishKey{1, "4", "andar:3"}]; ok || got[publishKey{1, "2", "andar:3"}] != 1 {
+		t.Errorf("floor rows not replaced: %v", got)
+	}
+}
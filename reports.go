@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Um período do início ao fim: export, métricas, --publish e os relatórios
+// tirados do CSV (KPIs, mapa de calor, fatores, stats, metas e deck). É a
+// mesma sequência no modo normal, em cada mês do backfill e em cada unidade
+// do --units; quem chama só resolve os caminhos e junta os resultados.
+
+// reportOptions são as flags de relatório de um período. KPI, Heatmap,
+// Drivers, Stats e Alerts têm o valor da flag ("auto" grava ao lado do CSV).
+type reportOptions struct {
+	Export   exportOptions // com o fuso da fonte em DBLoc
+	PPTXOpts pptxOptions
+	KPI      string
+	Heatmap  string
+	Drivers  string
+	Compare  *comparison
+	Stats    string
+	Alerts   string
+	// PPTX é o caminho do deck ("" = sem deck). SkipEmptyPPTX: período sem
+	// respostas fica sem deck em vez de dar erro (backfill e --units).
+	PPTX          string
+	SkipEmptyPPTX bool
+	// Unit vai na frente do período nas métricas e nos alertas (--units).
+	Unit string
+	// Publish nil = sem --publish. PubMu serializa a gravação entre os meses
+	// do backfill (nil fora dele).
+	Publish   *publishTarget
+	PubTable  string
+	PubFloors bool
+	PubMu     *sync.Mutex
+}
+
+// batch ajusta as opções para um período do backfill ou do --units: cada
+// relatório pedido vai ao lado do CSV do período e período sem respostas fica
+// sem deck.
+func (o reportOptions) batch() reportOptions {
+	o.KPI, o.Heatmap, o.Drivers = autoIfSet(o.KPI), autoIfSet(o.Heatmap), autoIfSet(o.Drivers)
+	o.Stats, o.Alerts = autoIfSet(o.Stats), autoIfSet(o.Alerts)
+	o.SkipEmptyPPTX = true
+	return o
+}
+
+// periodResult é o que um período gravou. Answers são as respostas do CSV,
+// lidas uma vez (nil sem csv no --format), para os KPIs do aviso.
+type periodResult struct {
+	Rows    int
+	Skipped int
+	Paths   []string
+	Alerts  int
+	Answers *periodAnswers
+}
+
+// runPeriodReports exporta [start, end) para outPath e gera os relatórios
+// pedidos. Com erro, o resultado traz o que já foi gravado.
+func runPeriodReports(ctx context.Context, db *sql.DB, engine string, start, end time.Time, outPath string, opts reportOptions) (periodResult, error) {
+	var res periodResult
+	if opts.Export.DBLoc == nil {
+		opts.Export.DBLoc = time.Local
+	}
+	exp, err := exportPeriod(ctx, db, engine, start, end, outPath, opts.Export)
+	res.Rows, res.Skipped, res.Paths = exp.Rows, exp.Skipped, exp.Paths
+	if err != nil {
+		return res, withStage(errorStage(err, stageQuery), err)
+	}
+	last := end.Add(-time.Nanosecond)
+	if opts.Export.Dedupe {
+		fmt.Printf("OK: %d linhas exportadas (removidas %d duplicadas consecutivas) para %s (%s -> %s)\n", res.Rows, res.Skipped, strings.Join(res.Paths, ", "), start.Format(time.RFC3339), end.Format(time.RFC3339))
+	} else {
+		fmt.Printf("OK: %d linhas exportadas para %s (%s -> %s)\n", res.Rows, strings.Join(res.Paths, ", "), start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	label, period := periodLabel(start, end), msgs.periodShort(start, last)
+	if opts.Unit != "" {
+		label, period = opts.Unit+"_"+label, opts.Unit+" "+period
+	}
+	metrics.observeExport(label, exp)
+	// Todos os relatórios abaixo usam a mesma leitura do CSV exportado, no
+	// fuso do banco de onde ele saiu.
+	answers := newPeriodAnswers([]string{outPath}, opts.PPTXOpts, opts.Export.DBLoc)
+	if hasFormat(opts.Export.Formats, "csv") {
+		res.Answers = answers
+		metrics.observeScores(label, answers)
+	}
+
+	if opts.Publish != nil {
+		if opts.PubMu != nil {
+			opts.PubMu.Lock()
+		}
+		err := runPublish(ctx, db, *opts.Publish, opts.PubTable, outPath, label, opts.PubFloors)
+		if opts.PubMu != nil {
+			opts.PubMu.Unlock()
+		}
+		if err != nil {
+			return res, withStage(stagePublish, fmt.Errorf("publish: %w", err))
+		}
+	}
+
+	if err := runKPI(opts.KPI, answers, opts.PPTXOpts); err != nil {
+		return res, withStage(stageReport, fmt.Errorf("kpi: %w", err))
+	}
+	if err := runHeatmap(opts.Heatmap, answers); err != nil {
+		return res, withStage(stageReport, fmt.Errorf("heatmap: %w", err))
+	}
+	if err := runDrivers(opts.Drivers, answers, opts.PPTXOpts); err != nil {
+		return res, withStage(stageReport, fmt.Errorf("drivers: %w", err))
+	}
+
+	popts := opts.PPTXOpts
+	popts.ExportSkipped = res.Skipped
+	// Com "previous", cada período compara com o anterior a ele.
+	if popts.Compare, err = opts.Compare.forPeriod(ctx, db, engine, start, end, opts.Export); err != nil {
+		return res, withStage(errorStage(err, stageQuery), err)
+	}
+	if err := runStats(opts.Stats, answers, popts); err != nil {
+		return res, withStage(stageReport, fmt.Errorf("stats: %w", err))
+	}
+	alerts, err := runAlerts(opts.Alerts, answers, period, popts)
+	if err != nil {
+		return res, withStage(stageReport, fmt.Errorf("alerts: %w", err))
+	}
+	res.Alerts = len(alerts)
+	metrics.observeAlerts(label, res.Alerts)
+
+	if opts.PPTX == "" || (opts.SkipEmptyPPTX && res.Rows == 0) {
+		// Sem respostas não há gráfico; o CSV vazio já registra o período.
+		return res, nil
+	}
+	// end é exclusivo; o título usa o último instante incluído.
+	if err := maybeGeneratePPTX(answers, opts.PPTX, start, last, popts); err != nil {
+		return res, withStage(stagePPTX, fmt.Errorf("pptx: %w", err))
+	}
+	res.Paths = append(res.Paths, opts.PPTX)
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunPeriodReports(t *testing.T) {
+	db := sourceDB(t, "2025-12-01 10:00:00", "2025-12-02 11:00:00")
+	start, end := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	targets, err := parseTargets("1:topbox>=90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "r.csv")
+	opts := reportOptions{
+		Export:   exportOptions{Formats: []string{"csv"}, DBLoc: time.UTC},
+		PPTXOpts: pptxOptions{Targets: targets},
+		KPI:      "sim",
+		Alerts:   "sim",
+		Unit:     "principal",
+	}.batch()
+
+	res, err := runPeriodReports(context.Background(), db, engineSQLite, start, end, out, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 2 || res.Alerts != 0 || len(res.Paths) != 1 || res.Answers == nil {
+		t.Errorf("result = %+v", res)
+	}
+	for _, p := range []string{defaultKPIPath(out), filepath.Join(filepath.Dir(out), "r_alertas.json")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("missing %s: %v", filepath.Base(p), err)
+		}
+	}
+
+	// Período sem respostas no lote: sem deck e sem erro (e sem chamar o python).
+	opts.PPTX = filepath.Join(t.TempDir(), "r.pptx")
+	empty := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	res, err = runPeriodReports(context.Background(), db, engineSQLite, empty, empty.AddDate(0, 1, 0), out, opts)
+	if err != nil || res.Rows != 0 {
+		t.Errorf("empty period: %+v, %v", res, err)
+	}
+	if _, err := os.Stat(opts.PPTX); !os.IsNotExist(err) {
+		t.Errorf("deck written for an empty period: %v", err)
+	}
+}
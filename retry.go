@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+)
+
+// Novas tentativas com espera exponencial (--retries, --retry-wait) para as
+// falhas passageiras do banco remoto: conexão recusada ou derrubada, timeout,
+// "too many connections", deadlock, túnel SSH que caiu. Host que não existe,
+// porta inválida, senha, SQL ou disco não são repetidos: só atrasariam o erro.
+
+type retryPolicy struct {
+	Attempts int           // tentativas no total (1 = sem repetir)
+	Wait     time.Duration // espera antes da 2ª tentativa; dobra a cada uma
+	MaxWait  time.Duration
+}
+
+// dbRetry é configurado em main.
+var dbRetry = retryPolicy{Attempts: 3, Wait: 2 * time.Second, MaxWait: 30 * time.Second}
+
+// withRetry roda fn até dar certo, o erro não ser passageiro, acabarem as
+// tentativas ou ctx expirar. op aparece no log e nas métricas.
+func withRetry(ctx context.Context, op string, fn func() error) error {
+	wait := dbRetry.Wait
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= dbRetry.Attempts || !isTransient(err) {
+			return err
+		}
+		// ±20% para várias execuções (backfill, unidades) não baterem juntas.
+		d := wait + time.Duration((rand.Float64()*0.4-0.2)*float64(wait))
+		slog.Warn("falha passageira no banco; tentando de novo", "op", op, "attempt", attempt, "wait", d.Round(time.Millisecond).String(), "err", err)
+		metrics.observeRetry(op)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+		if wait *= 2; wait > dbRetry.MaxWait {
+			wait = dbRetry.MaxWait
+		}
+	}
+}
+
+// Erros do servidor MySQL que costumam passar sozinhos.
+var transientMySQL = map[uint16]bool{
+	1040: true, // too many connections
+	1053: true, // server shutdown in progress
+	1205: true, // lock wait timeout
+	1213: true, // deadlock
+}
+
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false // o limite da execução acabou; não adianta insistir
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return transientMySQL[me.Number]
+	}
+	var rejected *ssh.OpenChannelError
+	if errors.As(err, &rejected) {
+		return rejected.Reason == ssh.ConnectionFailed // MySQL fora do ar atrás do bastion
+	}
+	var de *net.DNSError
+	if errors.As(err, &de) {
+		return de.IsTimeout || de.IsTemporary // NXDOMAIN não se resolve sozinho
+	}
+	// Timeout de rede (net.Error: o Errno de disco também satisfaz a
+	// interface, mas sem Timeout).
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	// Servidor reiniciando (recusada) ou conexão derrubada no meio.
+	for _, target := range []error{driver.ErrBadConn, mysql.ErrInvalidConn, io.ErrUnexpectedEOF,
+		syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// pingDB é o PingContext com novas tentativas.
+func pingDB(ctx context.Context, db *sql.DB) error {
+	return withRetry(ctx, "ping", func() error { return db.PingContext(ctx) })
+}
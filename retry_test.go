@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsTransient(t *testing.T) {
+	dial := func(err error) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: err}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"mysql lock wait timeout", &mysql.MySQLError{Number: 1205}, true},
+		{"mysql deadlock", fmt.Errorf("export: %w", &mysql.MySQLError{Number: 1213}), true},
+		{"mysql too many connections", &mysql.MySQLError{Number: 1040}, true},
+		{"mysql access denied", &mysql.MySQLError{Number: 1045}, false},
+		{"mysql syntax error", &mysql.MySQLError{Number: 1064}, false},
+		{"connection refused", dial(os.NewSyscallError("connect", syscall.ECONNREFUSED)), true},
+		{"connection reset", fmt.Errorf("query: %w", syscall.ECONNRESET), true},
+		{"network timeout", dial(os.ErrDeadlineExceeded), true},
+		{"bad port", dial(&net.AddrError{Err: "invalid port", Addr: "db:99999"}), false},
+		{"dns not found", dial(&net.DNSError{Err: "no such host", Name: "db.invalid", IsNotFound: true}), false},
+		{"dns temporary", &net.DNSError{Err: "server misbehaving", Name: "db", IsTemporary: true}, true},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "db", IsTimeout: true}, true},
+		{"bad conn", driver.ErrBadConn, true},
+		{"mysql invalid conn", mysql.ErrInvalidConn, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"bare eof", io.EOF, false},
+		{"ssh channel refused by bastion", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed}, true},
+		{"ssh channel prohibited", &ssh.OpenChannelError{Reason: ssh.Prohibited}, false},
+		{"local file", fmt.Errorf("create csv: %w", &fs.PathError{Op: "open", Path: "/tmp", Err: syscall.EISDIR}), false},
+		{"context canceled", fmt.Errorf("ping: %w", context.Canceled), false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	saved := dbRetry
+	t.Cleanup(func() { dbRetry = saved })
+	dbRetry = retryPolicy{Attempts: 3, Wait: time.Millisecond, MaxWait: 2 * time.Millisecond}
+
+	transient := &mysql.MySQLError{Number: 1213}
+	permanent := &mysql.MySQLError{Number: 1045}
+	tests := []struct {
+		name      string
+		errs      []error // resultado de cada chamada; depois do fim, nil
+		cancel    bool
+		wantCalls int
+		wantErr   error
+	}{
+		{"ok first try", nil, false, 1, nil},
+		{"transient then ok", []error{transient}, false, 2, nil},
+		{"permanent not retried", []error{permanent, nil}, false, 1, permanent},
+		{"gives up after attempts", []error{transient, transient, transient, nil}, false, 3, transient},
+		{"canceled context stops waiting", []error{transient, nil}, true, 1, transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			calls := 0
+			err := withRetry(ctx, "test", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) && err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSSHTunnelClosedRefusesDial(t *testing.T) {
+	tun := &sshTunnel{addr: "bastion:22", config: &ssh.ClientConfig{}}
+	if err := tun.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tun.dial(context.Background(), "db:3306"); err == nil {
+		t.Fatal("dial after Close should fail")
+	}
+}
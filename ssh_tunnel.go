@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Túnel SSH até o MySQL (--ssh-host, --ssh-user, --ssh-key, --ssh-known-hosts):
+// o DBA só libera o banco pelo bastion. As conexões do driver passam por uma
+// rede registrada no go-sql-driver/mysql ("ssh"), então o DSN continua com o
+// host:porta do MySQL visto a partir do bastion:
+//
+//	user:pass@ssh(mysql.interno:3306)/hospital
+//
+// A conexão SSH abre na primeira conexão ao banco e é refeita se cair.
+
+const sshNet = "ssh"
+
+type sshOptions struct {
+	Host       string // bastion, host[:porta]
+	User       string
+	KeyFile    string
+	KnownHosts string // padrão ~/.ssh/known_hosts
+}
+
+// sshOptionsFromEnv completa as flags vazias com SSH_HOST, SSH_USER,
+// SSH_KEY_FILE e SSH_KNOWN_HOSTS.
+func sshOptionsFromEnv(o sshOptions) sshOptions {
+	o.Host = firstNonEmpty(strings.TrimSpace(o.Host), os.Getenv("SSH_HOST"))
+	o.User = firstNonEmpty(strings.TrimSpace(o.User), os.Getenv("SSH_USER"))
+	o.KeyFile = firstNonEmpty(strings.TrimSpace(o.KeyFile), os.Getenv("SSH_KEY_FILE"))
+	o.KnownHosts = firstNonEmpty(strings.TrimSpace(o.KnownHosts), os.Getenv("SSH_KNOWN_HOSTS"))
+	return o
+}
+
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool // depois do Close o driver não reabre a sessão
+}
+
+// newSSHTunnel valida chave e known_hosts e registra a rede "ssh" no driver.
+// nil sem --ssh-host.
+func newSSHTunnel(o sshOptions) (*sshTunnel, error) {
+	if o.Host == "" {
+		if o.User != "" || o.KeyFile != "" {
+			return nil, errors.New("--ssh-user/--ssh-key need --ssh-host")
+		}
+		return nil, nil
+	}
+	if o.User == "" || o.KeyFile == "" {
+		return nil, errors.New("--ssh-host needs --ssh-user and --ssh-key")
+	}
+	addr := o.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	key, err := os.ReadFile(o.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("--ssh-key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		pass := os.Getenv("SSH_KEY_PASSPHRASE")
+		if pass == "" {
+			return nil, fmt.Errorf("--ssh-key %s is encrypted: set SSH_KEY_PASSPHRASE", o.KeyFile)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(pass))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("--ssh-key: %w", err)
+	}
+
+	known := o.KnownHosts
+	if known == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("--ssh-known-hosts: %w", err)
+		}
+		known = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	// Sem known_hosts não há como saber se o bastion é ele mesmo; não há
+	// opção para pular a verificação.
+	hostKey, err := knownhosts.New(known)
+	if err != nil {
+		host, port, _ := net.SplitHostPort(addr)
+		scan := "ssh-keyscan -H " + host
+		if port != "22" {
+			scan = "ssh-keyscan -H -p " + port + " " + host
+		}
+		return nil, fmt.Errorf("--ssh-known-hosts: %w (add the bastion with: %s >> %s)", err, scan, known)
+	}
+
+	t := &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            o.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKey,
+			Timeout:         15 * time.Second,
+		},
+	}
+	mysql.RegisterDialContext(sshNet, t.dial)
+	return t, nil
+}
+
+// dial abre um canal direct-tcpip até addr (o host:porta do MySQL).
+func (t *sshTunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	client, err := t.connect()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, "tcp", addr)
+	if err == nil {
+		return conn, nil
+	}
+	// Canal recusado é o bastion dizendo que não alcança o MySQL; qualquer
+	// outro erro é a sessão SSH que caiu (bastion reiniciado, NAT): descarta e
+	// deixa a próxima tentativa (ver retry.go) abrir outra.
+	var rejected *ssh.OpenChannelError
+	if !errors.As(err, &rejected) {
+		t.mu.Lock()
+		if t.client == client {
+			t.client.Close()
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}
+	return nil, fmt.Errorf("ssh %s -> %s: %w", t.addr, addr, err)
+}
+
+func (t *sshTunnel) connect() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, fmt.Errorf("ssh %s: tunnel closed", t.addr)
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+	client, err := ssh.Dial("tcp", t.addr, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	t.client = client
+	return client, nil
+}
+
+// Close fecha a sessão de vez: uma conexão que o database/sql ainda abra em
+// segundo plano depois disso falha em vez de deixar outra sessão aberta.
+func (t *sshTunnel) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
+
+// viaSSH troca tcp(host:porta) por ssh(host:porta) no DSN do MySQL; sem
+// endereço vale o padrão do driver (127.0.0.1:3306, agora visto do bastion).
+func viaSSH(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("--ssh-host: %w", err)
+	}
+	if cfg.Net != "tcp" {
+		return "", fmt.Errorf("--ssh-host needs a tcp(host:port) DSN, not %s", cfg.Net)
+	}
+	cfg.Net = sshNet
+	return cfg.FormatDSN(), nil
+}
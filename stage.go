@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+)
+
+// Etapas da execução com banco. Cada falha é contada na sua etapa
+// (auto_relatorio_errors_last_run{stage=...}, ver metrics.go).
+const (
+	stageConfig  = "config"  // flags, .env, fuso, período
+	stageDB      = "db"      // abrir/pingar o banco
+	stageQuery   = "query"   // query e leitura das linhas
+	stageWrite   = "write"   // CSV/JSONL/Parquet
+	stagePublish = "publish" // --publish
+	stageReport  = "report"  // --kpi, --stats, --targets
+	stagePPTX    = "pptx"
+)
+
+var stages = []string{stageConfig, stageDB, stageQuery, stageWrite, stagePublish, stageReport, stagePPTX}
+
+// stageError marca em que etapa um erro aconteceu; a mensagem não muda.
+type stageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *stageError) Error() string { return e.Err.Error() }
+func (e *stageError) Unwrap() error { return e.Err }
+
+func withStage(stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stageError{Stage: stage, Err: err}
+}
+
+// errorStage devolve a etapa marcada em err, ou fallback.
+func errorStage(err error, fallback string) string {
+	var se *stageError
+	if errors.As(err, &se) {
+		return se.Stage
+	}
+	return fallback
+}
+
+// removeOnError apaga path se *err != nil, para uma falha não deixar arquivo
+// pela metade. Uso: defer removeOnError(path, &err), antes do defer f.Close().
+func removeOnError(path string, err *error) {
+	if *err != nil {
+		_ = os.Remove(path)
+	}
+}
+
+// Códigos de saída (documentados no README). 2 é o mesmo do pacote flag
+// para flag inválida; 3 (exitAlert, alerts.go) é meta não atingida.
+const (
+	exitError   = 1 // erro sem etapa conhecida
+	exitConfig  = 2
+	exitDB      = 4
+	exitQuery   = 5
+	exitWrite   = 6
+	exitPublish = 7
+	exitReport  = 8
+	exitPPTX    = 9
+)
+
+var stageExit = map[string]int{
+	stageConfig:  exitConfig,
+	stageDB:      exitDB,
+	stageQuery:   exitQuery,
+	stageWrite:   exitWrite,
+	stagePublish: exitPublish,
+	stageReport:  exitReport,
+	stagePPTX:    exitPPTX,
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ae *alertsErr
+	if errors.As(err, &ae) {
+		return exitAlert
+	}
+	if code, ok := stageExit[errorStage(err, "")]; ok {
+		return code
+	}
+	return exitError
+}
+
+// finishRun registra o fim da execução: a falha vai para o log, para as
+// métricas (errors_last_run na etapa) e para o webhook.
+func finishRun(err error) error {
+	var ae *alertsErr
+	switch {
+	case err == nil:
+		slog.Info("fim")
+	case errors.As(err, &ae):
+		slog.Warn("metas não atingidas", "alerts", ae.N)
+	default:
+		slog.Error(err.Error(), "stage", errorStage(err, "unknown"))
+		metrics.countError(err)
+		metrics.finish(false)
+		notifyFailure(err.Error())
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	base := errors.New("falhou")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"no stage", base, exitError},
+		{"config", withStage(stageConfig, base), 2},
+		{"alert", alertsError(1), 3},
+		{"db", withStage(stageDB, base), 4},
+		{"query", withStage(stageQuery, base), 5},
+		{"write", withStage(stageWrite, base), 6},
+		{"publish", withStage(stagePublish, base), 7},
+		{"report", withStage(stageReport, base), 8},
+		{"pptx", withStage(stagePPTX, base), 9},
+		{"unknown stage", withStage("outra", base), exitError},
+		{"wrapped", fmt.Errorf("unit hd: %w", withStage(stageQuery, base)), exitQuery},
+		{"wrapped twice", fmt.Errorf("run: %w", fmt.Errorf("unit hd: %w", withStage(stagePublish, base))), exitPublish},
+		// Vários meses/unidades: vale a etapa da primeira falha.
+		{"batch", &batchError{Op: "backfill", What: "months", Failed: 2, Total: 3,
+			Errs: []error{withStage(stageDB, base), withStage(stageWrite, base)}}, exitDB},
+		{"batch without stage", &batchError{Op: "units", What: "units", Failed: 1, Total: 2, Errs: []error{base}}, exitError},
+		{"wrapped batch", fmt.Errorf("backfill: %w", &batchError{Failed: 1, Total: 1, Errs: []error{withStage(stagePPTX, base)}}), exitPPTX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+	// Toda etapa tem código próprio.
+	for _, s := range stages {
+		if _, ok := stageExit[s]; !ok {
+			t.Errorf("stage %q has no exit code", s)
+		}
+	}
+}
+
+func TestWithStage(t *testing.T) {
+	if withStage(stageDB, nil) != nil {
+		t.Error("withStage(nil) must be nil")
+	}
+	err := withStage(stageWrite, fmt.Errorf("write: %w", os.ErrPermission))
+	if err.Error() != "write: permission denied" {
+		t.Errorf("message changed: %q", err)
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Error("stageError must unwrap to the cause")
+	}
+	// Com duas marcas vale a de fora (errors.As para na primeira).
+	twice := withStage(stagePublish, withStage(stageQuery, os.ErrClosed))
+	if got := errorStage(twice, ""); got != stagePublish {
+		t.Errorf("errorStage = %q, want the outermost mark %q", got, stagePublish)
+	}
+	if got := errorStage(os.ErrClosed, "unknown"); got != "unknown" {
+		t.Errorf("errorStage fallback = %q", got)
+	}
+}
+
+func TestRemoveOnError(t *testing.T) {
+	dir := t.TempDir()
+	for _, fail := range []bool{false, true} {
+		path := filepath.Join(dir, fmt.Sprintf("saida_%v.csv", fail))
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if fail {
+			err = errors.New("falhou")
+		}
+		removeOnError(path, &err)
+		if _, statErr := os.Stat(path); (statErr == nil) == fail {
+			t.Errorf("fail=%v: file exists = %v", fail, statErr == nil)
+		}
+	}
+}
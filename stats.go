@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tamanho de amostra e intervalos de confiança.
+//
+// Um andar com 6 respostas gera uma pizza tão "convincente" quanto uma com 600;
+// por isso cada gráfico mostra o n, cada percentual vem com o intervalo de
+// Wilson (95%) e, abaixo de --min-n, o gráfico sai em cinza com um aviso.
+
+// z de 95% bilateral.
+const ciZ = 1.959964
+
+const defaultMinN = 30
+
+// wilson devolve o intervalo de Wilson (0..1) para k sucessos em n.
+// Ao contrário do intervalo normal, não sai de [0, 1] nem colapsa em 0% ou 100%.
+func wilson(k, n int) (lo, hi float64) {
+	if n <= 0 {
+		return 0, 0
+	}
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	z2 := ciZ * ciZ
+	den := 1 + z2/nf
+	center := (p + z2/(2*nf)) / den
+	half := ciZ * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / den
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
+
+// kpiRow é uma linha do CSV de KPIs: uma resposta de uma pergunta num segmento.
+type kpiRow struct {
+	Segmento   string
+	Questao    int
+	Titulo     string
+	Resposta   string
+	Quantidade int
+	N          int
+	Pct        float64 // 0..100
+	Lo, Hi     float64 // 0..100
+	LowN       bool
+}
+
+var kpiHeader = []string{"segmento", "questao", "titulo", "resposta", "quantidade", "n", "percentual", "ic95_inferior", "ic95_superior", "n_baixo"}
+
+// kpiRows calcula n, % e IC de cada resposta, no geral e por andar.
+func kpiRows(ac *answerCounts, minN int) []kpiRow {
+	var out []kpiRow
+	add := func(segment string, counts []map[string]int) {
+		for i, qc := range ac.Questions {
+			n := 0
+			for _, c := range counts[i] {
+				n += c
+			}
+			if n == 0 {
+				continue
+			}
+			for _, label := range sortedAnswers(counts[i]) {
+				k := counts[i][label]
+				lo, hi := wilson(k, n)
+				out = append(out, kpiRow{
+					Segmento:   segment,
+					Questao:    qc.Number,
+					Titulo:     qc.Title,
+					Resposta:   label,
+					Quantidade: k,
+					N:          n,
+					Pct:        float64(k) / float64(n) * 100,
+					Lo:         lo * 100,
+					Hi:         hi * 100,
+					LowN:       n < minN,
+				})
+			}
+		}
+	}
+	add("geral", ac.Total)
+	floors := make([]string, 0, len(ac.ByFloor))
+	for f := range ac.ByFloor {
+		floors = append(floors, f)
+	}
+	sort.Strings(floors)
+	for _, f := range floors {
+		add(floorSegment(f), ac.ByFloor[f])
+	}
+	return out
+}
+
+// defaultKPIPath: relatorio_2025_12.csv -> relatorio_2025_12_kpi.csv
+func defaultKPIPath(csvPath string) string {
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "_kpi.csv"
+}
+
+// writeKPICSV grava os KPIs com o mesmo dialeto do CSV principal.
+func writeKPICSV(path string, ac *answerCounts, minN int) (int, error) {
+	pct := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	var rows [][]string
+	for _, r := range kpiRows(ac, minN) {
+		lowN := "0"
+		if r.LowN {
+			lowN = "1"
+		}
+		rows = append(rows, []string{
+			r.Segmento, strconv.Itoa(r.Questao), r.Titulo, r.Resposta,
+			strconv.Itoa(r.Quantidade), strconv.Itoa(r.N),
+			pct(r.Pct), pct(r.Lo), pct(r.Hi), lowN,
+		})
+	}
+	return len(rows), writeDialectCSV(path, kpiHeader, rows)
+}
+
+// kpiPathFor resolve --kpi: "auto" grava ao lado do CSV (só com um CSV).
+func kpiPathFor(kpiFlag string, csvPaths []string) (string, error) {
+	kpiFlag = strings.TrimSpace(kpiFlag)
+	if !strings.EqualFold(kpiFlag, "auto") {
+		return kpiFlag, nil
+	}
+	if len(csvPaths) != 1 {
+		return "", fmt.Errorf("--kpi=auto with %d CSVs: pass a file path instead", len(csvPaths))
+	}
+	return defaultKPIPath(csvPaths[0]), nil
+}
+
+// runKPI grava o CSV de KPIs das respostas do período.
+func runKPI(kpiFlag string, in *periodAnswers, opts pptxOptions) error {
+	if strings.TrimSpace(kpiFlag) == "" {
+		return nil
+	}
+	path, err := kpiPathFor(kpiFlag, in.Paths)
+	if err != nil {
+		return err
+	}
+	merged, err := in.load()
+	if err != nil {
+		return err
+	}
+	n, err := writeKPICSV(path, merged.Counts, opts.MinN)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("OK: %d KPIs (n, %% e IC 95%%) gravados em %s\n", n, path)
+	return nil
+}
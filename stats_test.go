@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWilson(t *testing.T) {
+	// Referências: Newcombe (1998), Statistics in Medicine 17:857, tabela II
+	// (método 3, score de Wilson sem correção de continuidade), 4 casas.
+	tests := []struct {
+		name   string
+		k, n   int
+		lo, hi float64
+	}{
+		{"81/263", 81, 263, 0.2553, 0.3662},
+		{"15/148", 15, 148, 0.0624, 0.1605},
+		{"0/20 (p=0)", 0, 20, 0, 0.1611},
+		{"1/29", 1, 29, 0.0061, 0.1718},
+		{"0/10 (p=0)", 0, 10, 0, 0.2775},
+		{"10/10 (p=1)", 10, 10, 0.7225, 1},
+		{"n=0", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lo, hi := wilson(tt.k, tt.n)
+			if math.Abs(lo-tt.lo) > 5e-5 || math.Abs(hi-tt.hi) > 5e-5 {
+				t.Errorf("wilson(%d, %d) = [%.4f, %.4f], want [%.4f, %.4f]", tt.k, tt.n, lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestWilsonSymmetry(t *testing.T) {
+	// k de n e n-k de n são espelhos: [lo, hi] <-> [1-hi, 1-lo].
+	for _, c := range [][2]int{{3, 17}, {0, 5}, {40, 41}} {
+		lo, hi := wilson(c[0], c[1])
+		lo2, hi2 := wilson(c[1]-c[0], c[1])
+		if math.Abs(lo-(1-hi2)) > 1e-12 || math.Abs(hi-(1-lo2)) > 1e-12 {
+			t.Errorf("wilson(%d, %d) = [%g, %g] not mirrored by [%g, %g]", c[0], c[1], lo, hi, lo2, hi2)
+		}
+		if p := float64(c[0]) / float64(c[1]); p < lo || p > hi {
+			t.Errorf("wilson(%d, %d) = [%g, %g] excludes p = %g", c[0], c[1], lo, hi, p)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Várias unidades (--units principal,hospital_dia): cada unidade tem o seu
+// banco adms_*, configurado no "db" do perfil de mesmo nome do --config. O
+// período é o mesmo para todas e cada uma grava os seus arquivos com o nome
+// na frente (principal_relatorio_2025_12.csv, ...). No fim sai o consolidado:
+// relatorio_2025_12_unidades.csv, com a coluna Unidade, e com --pptx um deck
+// que compara as unidades em cada pergunta.
+//
+// As unidades rodam uma de cada vez; cada uma exporta com o fuso do seu banco
+// (exportOptions.DBLoc). A falha de uma não interrompe as outras, mas sem
+// todas não há consolidado.
+
+type unitResult struct {
+	Unit    unitSource
+	CSV     string // CSV da unidade (entra no consolidado)
+	Rows    int
+	Skipped int
+	Paths   []string
+	Alerts  int
+	Err     error
+	Elapsed time.Duration
+}
+
+type unitsOptions struct {
+	PPTX string // --pptx; "auto" ou caminho, prefixado com a unidade
+	// Reports: qualquer --kpi, --heatmap etc. não vazio grava <csv>_kpi.csv
+	// (e os demais) de cada unidade.
+	Reports reportOptions
+	DBTZ    string // --db-tz, para unidade sem db-tz no perfil
+	// TZ é o fuso do período (--tz), para o aviso de fusos de cada unidade.
+	// CSVLoc (--db-tz) lê o consolidado, em que cada linha segue na hora de
+	// parede da sua unidade.
+	TZ     *time.Location
+	CSVLoc *time.Location
+}
+
+// unitPath põe a unidade na frente do nome do arquivo, na mesma pasta.
+func unitPath(unit, path string) string {
+	return filepath.Join(filepath.Dir(path), unit+"_"+filepath.Base(path))
+}
+
+// consolidatedPath: relatorio_2025_12.csv -> relatorio_2025_12_unidades.csv.
+func consolidatedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_unidades" + ext
+}
+
+func runUnits(units []unitSource, start, end time.Time, outPath string, opts unitsOptions) []unitResult {
+	results := make([]unitResult, len(units))
+	for i, u := range units {
+		results[i] = runUnit(u, start, end, unitPath(u.Name, outPath), opts)
+	}
+	return results
+}
+
+func runUnit(u unitSource, start, end time.Time, outPath string, opts unitsOptions) (res unitResult) {
+	res.Unit, res.CSV = u, outPath
+	began := time.Now()
+	defer func() { res.Elapsed = time.Since(began) }()
+
+	loc, err := resolveDBTZ(firstNonEmpty(u.DBTZ, opts.DBTZ), u.Engine, u.DSN)
+	if err != nil {
+		res.Err = withStage(stageConfig, err)
+		return res
+	}
+	db, err := openSource(u.Engine, u.DSN)
+	if err != nil {
+		res.Err = withStage(stageDB, fmt.Errorf("open db: %w", err))
+		return res
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := pingDB(ctx, db); err != nil {
+		res.Err = withStage(stageDB, fmt.Errorf("ping db: %w", err))
+		return res
+	}
+
+	if opts.TZ != nil {
+		if w := warnTZ(opts.TZ, loc, start); w != "" {
+			slog.Warn(w, "unit", u.Name)
+		}
+	}
+	ropts := opts.Reports.batch()
+	ropts.Unit, ropts.Export.DBLoc = u.Name, loc
+	if opts.PPTX != "" {
+		ropts.PPTX = unitPath(u.Name, pptxPathFor(opts.PPTX, start, end.Add(-time.Nanosecond)))
+	}
+	pr, err := runPeriodReports(ctx, db, u.Engine, start, end, outPath, ropts)
+	res.Rows, res.Skipped, res.Paths, res.Alerts, res.Err = pr.Rows, pr.Skipped, pr.Paths, pr.Alerts, err
+	return res
+}
+
+// printUnitsSummary imprime uma linha por unidade e devolve quantas falharam.
+func printUnitsSummary(results []unitResult) int {
+	failed := 0
+	fmt.Println("Resumo das unidades:")
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("  %-16s FALHOU  %v\n", r.Unit.Name, r.Err)
+			continue
+		}
+		alerts := ""
+		if r.Alerts > 0 {
+			alerts = fmt.Sprintf(" - %d metas não atingidas", r.Alerts)
+		}
+		fmt.Printf("  %-16s OK      %d linhas (%d duplicadas removidas) em %s [%s]%s\n", r.Unit.Name, r.Rows, r.Skipped, r.CSV, r.Elapsed.Round(time.Millisecond), alerts)
+	}
+	return failed
+}
+
+func unitsErr(results []unitResult, failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	e := &batchError{Op: "units", What: "units", Failed: failed, Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			e.Errs = append(e.Errs, r.Err)
+		}
+	}
+	return e
+}
+
+// runConsolidated grava o CSV com a coluna Unidade e, com --pptx, o deck
+// comparando as unidades. Devolve os arquivos gravados e as respostas do
+// consolidado (para o aviso não ler o CSV de novo).
+func runConsolidated(results []unitResult, start, end time.Time, outPath string, opts unitsOptions) ([]string, *periodAnswers, error) {
+	csvPath := consolidatedPath(outPath)
+	if err := writeUnitsCSV(csvPath, results); err != nil {
+		return nil, nil, withStage(stageWrite, err)
+	}
+	answers := newPeriodAnswers([]string{csvPath}, opts.Reports.PPTXOpts, opts.CSVLoc)
+	rows, skipped := 0, 0
+	for _, r := range results {
+		rows += r.Rows
+		skipped += r.Skipped
+	}
+	fmt.Printf("OK: %d linhas de %d unidades consolidadas em %s\n", rows, len(results), csvPath)
+	paths := []string{csvPath}
+
+	if opts.PPTX == "" || rows == 0 {
+		return paths, answers, nil
+	}
+	last := end.Add(-time.Nanosecond)
+	// A comparação com outro período é por unidade; aqui o deck só junta.
+	popts := opts.Reports.PPTXOpts
+	popts.Compare, popts.ExportSkipped = nil, skipped
+	pptxPath := consolidatedPath(pptxPathFor(opts.PPTX, start, last))
+	if err := maybeGeneratePPTX(answers, pptxPath, start, last, popts); err != nil {
+		return paths, answers, withStage(stagePPTX, fmt.Errorf("pptx: %w", err))
+	}
+	return append(paths, pptxPath), answers, nil
+}
+
+// writeUnitsCSV junta os CSVs das unidades (já no dialeto de saída) com a
+// unidade na primeira coluna.
+func writeUnitsCSV(path string, results []unitResult) error {
+	// As linhas copiadas já estão no dialeto: não passam de novo por formatRecord.
+	return createDialectCSV(path, append([]string{msgs.Unit}, msgs.Header...), func(w *dialectWriter) error {
+		for _, r := range results {
+			if err := copyUnitRows(w, r.Unit.Name, r.CSV); err != nil {
+				return fmt.Errorf("%s: %w", r.CSV, err)
+			}
+		}
+		return nil
+	})
+}
+
+func copyUnitRows(w *dialectWriter, unit, csvPath string) error {
+	f, err := os.Open(csvPath)
+	if err != nil {
+		return fmt.Errorf("open csv: %w", err)
+	}
+	defer f.Close()
+	r := dialect.newReader(f)
+	if _, err := r.Read(); err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read csv: %w", err)
+		}
+		if err := w.Write(append([]string{unit}, row...)); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+}
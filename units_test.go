@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sourceDB cria um banco SQLite com o esquema da query e uma resposta por
+// created (hora de parede, como o DATETIME do MySQL).
+func sourceDB(t *testing.T, created ...string) *sql.DB {
+	t.Helper()
+	db, err := openSource(engineSQLite, sourceDSN(t, created...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// sourceDSN é o sourceDB para quem abre o banco sozinho (--units).
+func sourceDSN(t *testing.T, created ...string) string {
+	t.Helper()
+	dsn := "sqlite:" + filepath.Join(t.TempDir(), "src.db")
+	db, err := openSource(engineSQLite, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	stmts := []string{
+		`CREATE TABLE adms_leitos (id INTEGER PRIMARY KEY, num_andar INTEGER)`,
+		`CREATE TABLE adms_paciente (id INTEGER PRIMARY KEY, nome_paciente TEXT)`,
+		`CREATE TABLE adms_experiencia_questoes (id INTEGER PRIMARY KEY, adms_leito_id INT, adms_paciente_id INT, created DATETIME, cadastrador INT,
+			questao1 TEXT, questao2 TEXT, questao3 TEXT, questao4 TEXT, questao5 TEXT, questao6 TEXT, questao7 TEXT, questao8 TEXT, questao9 TEXT, questao10 TEXT,
+			questao11 TEXT, questao12 TEXT, questao13 TEXT, questao14 TEXT, questao15 TEXT, questao16 TEXT, questao17 TEXT, questao18 TEXT, questao19 TEXT, questao20 TEXT)`,
+		`INSERT INTO adms_leitos VALUES (1, 3)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, c := range created {
+		if _, err := db.Exec(`INSERT INTO adms_paciente VALUES (?, ?)`, i+1, "P"+string(rune('A'+i))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO adms_experiencia_questoes (adms_leito_id, adms_paciente_id, created, questao1) VALUES (1, ?, ?, '4')`, i+1, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dsn
+}
+
+func TestExportPeriodUsesSourceZone(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	db := sourceDB(t, "2025-11-30 23:30:00", "2025-12-01 01:00:00", "2025-12-31 23:00:00")
+	start, end := time.Date(2025, 12, 1, 0, 0, 0, 0, sp), time.Date(2026, 1, 1, 0, 0, 0, 0, sp)
+
+	tests := []struct {
+		name  string
+		loc   *time.Location
+		dates []string // Data - Criação exportada
+	}{
+		// Banco em -03: os limites são a meia-noite local.
+		{"unit in Sao Paulo", sp, []string{"2025-12-01 01:00:00", "2025-12-31 23:00:00"}},
+		// Banco em UTC: dezembro em -03 vai de 03:00 do dia 1 a 03:00 de 1/1.
+		{"unit in UTC", time.UTC, []string{"2025-12-31 23:00:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "r.csv")
+			res, err := exportPeriod(context.Background(), db, engineSQLite, start, end, out, exportOptions{Formats: []string{"csv"}, DBLoc: tt.loc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Rows != len(tt.dates) {
+				t.Errorf("%d rows, want %d", res.Rows, len(tt.dates))
+			}
+			b, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range tt.dates {
+				if !strings.Contains(string(b), d) {
+					t.Errorf("missing %s in:\n%s", d, b)
+				}
+			}
+		})
+	}
+}
+
+func TestRunUnitsEachZone(t *testing.T) {
+	sp := mustLoc(t, "America/Sao_Paulo")
+	created := []string{"2025-12-01 01:00:00", "2025-12-10 12:00:00"}
+	units := []unitSource{
+		{Name: "sp", Engine: engineSQLite, DSN: sourceDSN(t, created...), DBTZ: "America/Sao_Paulo"},
+		{Name: "utc", Engine: engineSQLite, DSN: sourceDSN(t, created...), DBTZ: "UTC"},
+	}
+	start, end := time.Date(2025, 12, 1, 0, 0, 0, 0, sp), time.Date(2026, 1, 1, 0, 0, 0, 0, sp)
+	out := filepath.Join(t.TempDir(), "r.csv")
+	opts := unitsOptions{
+		Reports: reportOptions{Export: exportOptions{Formats: []string{"csv", "jsonl"}}},
+		TZ:      sp,
+		CSVLoc:  time.UTC,
+	}
+	results := runUnits(units, start, end, out, opts)
+
+	// 01:00 de 1/12 em UTC ainda é 30/11 em -03: fica fora só na unidade utc.
+	// A mesma hora de parede vira instantes diferentes no JSONL de cada uma.
+	want := map[string]struct {
+		rows    int
+		instant time.Time
+	}{
+		"sp":  {2, time.Date(2025, 12, 10, 15, 0, 0, 0, time.UTC)},
+		"utc": {1, time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, r := range results {
+		w := want[r.Unit.Name]
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Unit.Name, r.Err)
+		}
+		if r.Rows != w.rows {
+			t.Errorf("%s: %d rows, want %d", r.Unit.Name, r.Rows, w.rows)
+		}
+		b, err := os.ReadFile(formatPath(r.CSV, "jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		var last struct {
+			Created time.Time `json:"created"`
+		}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+			t.Fatal(err)
+		}
+		if !last.Created.Equal(w.instant) {
+			t.Errorf("%s: created = %v, want %v", r.Unit.Name, last.Created, w.instant)
+		}
+	}
+}